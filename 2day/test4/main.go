@@ -3,34 +3,27 @@ package main
 //我们在数据库操作的时候，比如 dao 层中当遇到一个 sql.ErrNoRows 的时候，是否应该 Wrap 这个 error，抛给上层。
 //为什么，应该怎么做请写出代码？
 import (
+	"context"
 	"fmt"
 	"time"
 )
 
 func main(){
 	message := make(chan int, 10)
-	done := make(chan bool)
 
-	defer close(message)
-
-	go func(){
-		ticker := time.NewTicker(time.Second)
-		for _ = range ticker.C{
-			select {
-			case <-done:
-				fmt.Println("child")
-				return
-			default:
-				fmt.Println("send")
-			}
-		}
+	// 原来靠close(done)通知子goroutine，现在用ctx控制，5秒后Worker自己返回
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	go func() {
+		// message由这里创建，也由这里在Worker返回后关闭
+		defer close(message)
+		Worker(ctx, message)
 	}()
-	for i:=0;i<10;i++{
-		message<-i
+	for i := range message {
+		fmt.Println("send", i)
 	}
-	time.Sleep(time.Second*5)
-	close(done)
-	time.Sleep(time.Second)
+	fmt.Println("child")
+	fmt.Println("consumed", len(produceConsume()))
 	fmt.Println("end")
 
 }
@@ -0,0 +1,20 @@
+package main
+
+// produceConsume 生产者发送10个数后关闭channel，消费者range到channel关闭为止，返回收到的值
+// 只由唯一的发送方（生产者）关闭channel，关闭时不会有别的goroutine还在发送，不会panic；
+// close本身就是"生产完了"的信号，range会先读完缓冲里剩下的值才退出，所以一个值都不会丢
+func produceConsume() []int {
+	const total = 10
+	message := make(chan int, total)
+	go func() {
+		defer close(message)
+		for i := 0; i < total; i++ {
+			message <- i
+		}
+	}()
+	got := make([]int, 0, total)
+	for v := range message {
+		got = append(got, v)
+	}
+	return got
+}
@@ -0,0 +1,18 @@
+package main
+
+import "testing"
+
+func TestProduceConsume(t *testing.T) {
+	// 多跑几次，关闭时机有问题的话迟早会panic或者丢值
+	for i := 0; i < 1000; i++ {
+		got := produceConsume()
+		if len(got) != 10 {
+			t.Fatalf("iteration %d: received %d values, want 10", i, len(got))
+		}
+		for j, v := range got {
+			if v != j {
+				t.Fatalf("iteration %d: got %v, want 0..9 in order", i, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"gostudy/retry"
+)
+
+// FailurePolicy 一次tick的工作重试后仍然失败时，SupervisedWorker怎么处理
+type FailurePolicy int
+
+const (
+	// StopOnFailure 把错误发到Errors()后停止
+	StopOnFailure FailurePolicy = iota
+	// ContinueOnFailure 把错误发到Errors()后等下一次tick继续
+	ContinueOnFailure
+)
+
+// SupervisedWorker 每隔interval执行一次work，出错时先重试retries次，
+// 仍然失败再把错误交给调用方，不会像Worker那样把错误悄悄吞掉
+type SupervisedWorker struct {
+	interval time.Duration
+	retries  int
+	backoff  time.Duration
+	policy   FailurePolicy
+	work     func(ctx context.Context) error
+	errs     chan error
+}
+
+// NewSupervisedWorker 创建worker，重试之间从backoff开始指数退避，最长不超过interval
+func NewSupervisedWorker(interval time.Duration, retries int, backoff time.Duration, policy FailurePolicy, work func(ctx context.Context) error) *SupervisedWorker {
+	return &SupervisedWorker{
+		interval: interval,
+		retries:  retries,
+		backoff:  backoff,
+		policy:   policy,
+		work:     work,
+		errs:     make(chan error, 1),
+	}
+}
+
+// Errors 重试用完仍然失败的错误，Run返回时关闭
+func (w *SupervisedWorker) Errors() <-chan error {
+	return w.errs
+}
+
+// Run 阻塞执行，直到ctx结束或者StopOnFailure策略下出现失败
+func (w *SupervisedWorker) Run(ctx context.Context) {
+	defer close(w.errs)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		err := retry.Retry(ctx, w.retries+1, retry.ExponentialJitter(w.backoff, w.interval), w.work)
+		if err == nil {
+			continue
+		}
+		// 因为ctx结束导致的失败不算work的错误
+		if ctx.Err() != nil {
+			return
+		}
+		select {
+		case w.errs <- err:
+		case <-ctx.Done():
+			return
+		}
+		if w.policy == StopOnFailure {
+			return
+		}
+	}
+}
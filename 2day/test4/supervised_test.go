@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSupervisedWorkerRetries(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var calls atomic.Int32
+	succeeded := make(chan struct{})
+	// 前两次失败，第三次成功，重试2次刚好够用
+	w := NewSupervisedWorker(time.Millisecond, 2, time.Millisecond, StopOnFailure, func(ctx context.Context) error {
+		switch n := calls.Add(1); {
+		case n <= 2:
+			return errors.New("flaky")
+		case n == 3:
+			cancel()
+			close(succeeded)
+		}
+		return nil
+	})
+	done := make(chan struct{})
+	go func() {
+		w.Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-succeeded:
+	case err := <-w.Errors():
+		t.Fatalf("error surfaced after %d calls: %v", calls.Load(), err)
+	case <-time.After(time.Second):
+		t.Fatal("work never succeeded")
+	}
+	<-done
+	if err, ok := <-w.Errors(); ok {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestSupervisedWorkerStopsOnFailure(t *testing.T) {
+	errBroken := errors.New("broken")
+	var calls atomic.Int32
+	w := NewSupervisedWorker(time.Millisecond, 2, time.Millisecond, StopOnFailure, func(ctx context.Context) error {
+		calls.Add(1)
+		return errBroken
+	})
+	done := make(chan struct{})
+	go func() {
+		w.Run(context.Background())
+		close(done)
+	}()
+
+	select {
+	case err := <-w.Errors():
+		if !errors.Is(err, errBroken) {
+			t.Fatalf("error = %v, want %v", err, errBroken)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("persistent failure not surfaced")
+	}
+	// StopOnFailure下Run要自己返回，不需要取消ctx
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("worker kept running under StopOnFailure")
+	}
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("work called %d times, want 3 (1 + 2 retries)", n)
+	}
+	if _, ok := <-w.Errors(); ok {
+		t.Fatal("Errors not closed after Run returned")
+	}
+}
+
+func TestSupervisedWorkerContinuesOnFailure(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := NewSupervisedWorker(time.Millisecond, 0, time.Millisecond, ContinueOnFailure, func(ctx context.Context) error {
+		return errors.New("broken")
+	})
+	done := make(chan struct{})
+	go func() {
+		w.Run(ctx)
+		close(done)
+	}()
+
+	// ContinueOnFailure下每个tick的失败都会报上来
+	for i := 0; i < 3; i++ {
+		select {
+		case <-w.Errors():
+		case <-time.After(time.Second):
+			t.Fatalf("got %d errors, want 3", i)
+		}
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("worker not stopped by cancel")
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// RunTicker 每隔interval往返回的channel里发送一个递增的数
+// ctx取消后停止ticker并关闭channel，调用方range到channel关闭即可，不会泄漏goroutine
+func RunTicker(ctx context.Context, interval time.Duration) <-chan int {
+	out := make(chan int)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		defer close(out)
+		for i := 0; ; i++ {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			// 发送时也要看ctx，否则没人接收会一直阻塞在这里
+			select {
+			case out <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// waitGoroutines 等goroutine数量降回n以内，goroutine退出需要一点时间
+func waitGoroutines(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > n {
+		if time.Now().After(deadline) {
+			t.Fatalf("goroutines = %d, want <= %d", runtime.NumGoroutine(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRunTickerStopsOnCancel(t *testing.T) {
+	before := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+	out := RunTicker(ctx, time.Millisecond)
+
+	for want := 0; want < 3; want++ {
+		if got := <-out; got != want {
+			t.Fatalf("got %d, want %d", got, want)
+		}
+	}
+	cancel()
+	// 取消后channel关闭，range能正常结束
+	for range out {
+	}
+	waitGoroutines(t, before)
+}
+
+func TestRunTickerCancelledContext(t *testing.T) {
+	before := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// 没有人接收时也不能卡在发送上
+	out := RunTicker(ctx, time.Millisecond)
+	select {
+	case <-waitClosed(out):
+	case <-time.After(time.Second):
+		t.Fatal("output channel not closed")
+	}
+	waitGoroutines(t, before)
+}
+
+// waitClosed 丢掉ch里剩下的值，ch关闭后关闭返回的channel
+func waitClosed(ch <-chan int) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		for range ch {
+		}
+		close(done)
+	}()
+	return done
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// workerInterval Worker发送的间隔
+var workerInterval = time.Second
+
+// Worker 按workerInterval往out发送递增的数，直到ctx结束后返回
+// out由调用方创建和关闭，Worker只负责发送
+func Worker(ctx context.Context, out chan<- int) {
+	ticker := time.NewTicker(workerInterval)
+	defer ticker.Stop()
+	for i := 0; ; i++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		// ctx和发送同时就绪时select随机选一个，先检查一次保证取消后不再发送
+		if ctx.Err() != nil {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case out <- i:
+		}
+	}
+}
+
+// StoppableWorker 每隔interval执行一次，Stop会等worker确认退出后才返回
+// 不用再靠time.Sleep猜子goroutine有没有结束
+type StoppableWorker struct {
+	interval time.Duration
+	// done关闭表示通知worker退出
+	done chan struct{}
+	// stopped由worker在退出时关闭，作为确认
+	stopped   chan struct{}
+	startOnce sync.Once
+	stopOnce  sync.Once
+}
+
+// NewStoppableWorker 创建worker，需要调用Start才会运行
+func NewStoppableWorker(interval time.Duration) *StoppableWorker {
+	return &StoppableWorker{
+		interval: interval,
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+}
+
+// Start 启动worker，多次调用只有第一次生效
+func (w *StoppableWorker) Start() {
+	w.startOnce.Do(func() {
+		go w.loop()
+	})
+}
+
+func (w *StoppableWorker) loop() {
+	defer close(w.stopped)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.done:
+			fmt.Println("child")
+			return
+		case <-ticker.C:
+			fmt.Println("send")
+		}
+	}
+}
+
+// Stop 通知worker退出并等待确认，ctx先结束则返回ctx.Err()
+// 可以重复调用；没有Start过的worker直接视为已停止
+func (w *StoppableWorker) Stop(ctx context.Context) error {
+	w.stopOnce.Do(func() {
+		close(w.done)
+	})
+	// 还没Start的话占掉startOnce，之后的Start不会再启动goroutine
+	w.startOnce.Do(func() {
+		close(w.stopped)
+	})
+	select {
+	case <-w.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestStoppableWorkerStop(t *testing.T) {
+	before := runtime.NumGoroutine()
+	w := NewStoppableWorker(time.Millisecond)
+	w.Start()
+	w.Start()
+	time.Sleep(5 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := w.Stop(ctx); err != nil {
+		t.Fatalf("Stop = %v, want nil", err)
+	}
+	// Stop返回时worker已经确认退出，goroutine随后结束
+	waitGoroutines(t, before)
+	if err := w.Stop(ctx); err != nil {
+		t.Fatalf("second Stop = %v, want nil", err)
+	}
+}
+
+func TestStoppableWorkerStopBeforeStart(t *testing.T) {
+	before := runtime.NumGoroutine()
+	w := NewStoppableWorker(time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := w.Stop(ctx); err != nil {
+		t.Fatalf("Stop = %v, want nil", err)
+	}
+	// Stop之后的Start不再启动goroutine
+	w.Start()
+	if n := runtime.NumGoroutine(); n > before {
+		t.Fatalf("goroutines = %d, want <= %d", n, before)
+	}
+}
+
+// fastWorker 测试期间把Worker的间隔调小
+func fastWorker(t *testing.T) {
+	t.Helper()
+	old := workerInterval
+	workerInterval = time.Millisecond
+	t.Cleanup(func() { workerInterval = old })
+}
+
+func TestWorkerStopsOnCancel(t *testing.T) {
+	fastWorker(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	out := make(chan int)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Worker(ctx, out)
+	}()
+
+	for want := 0; want < 3; want++ {
+		if got := <-out; got != want {
+			t.Fatalf("got %d, want %d", got, want)
+		}
+	}
+	// 没人接收时Worker阻塞在发送上，取消后也要马上返回
+	time.Sleep(5 * time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Worker did not return after cancel")
+	}
+	select {
+	case v := <-out:
+		t.Fatalf("Worker sent %d after cancellation", v)
+	default:
+	}
+}
+
+func TestWorkerCancelledContext(t *testing.T) {
+	fastWorker(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// 有缓冲也不能再发送
+	out := make(chan int, 10)
+	Worker(ctx, out)
+	if len(out) != 0 {
+		t.Fatalf("Worker sent %d values on a cancelled context", len(out))
+	}
+}
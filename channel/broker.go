@@ -0,0 +1,148 @@
+package channel
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// SlowPolicy 订阅者channel满了时Publish的处理方式
+type SlowPolicy int
+
+const (
+	// PolicyBlock 等订阅者读走再继续，保证不丢消息，但慢订阅者会拖慢Publish
+	PolicyBlock SlowPolicy = iota
+	// PolicyDrop 订阅者channel满了就丢掉这条消息，Publish不会阻塞
+	PolicyDrop
+)
+
+// Broker 简单的发布订阅，Publish的每条消息发给当前所有订阅者
+type Broker[T any] struct {
+	policy SlowPolicy
+	buffer int
+
+	mu     sync.RWMutex
+	subs   map[*subscriber[T]]struct{}
+	closed bool
+	// closing在Close一开始就置上，之后的Publish直接返回
+	closing atomic.Bool
+	// quit在Close的ctx结束时关闭，不需要拿锁，让阻塞中的Publish放弃投递退出
+	quit     chan struct{}
+	quitOnce sync.Once
+}
+
+type subscriber[T any] struct {
+	ch chan T
+	// done在取消订阅时关闭，让阻塞在这个订阅者上的Publish退出
+	done chan struct{}
+	once sync.Once
+}
+
+// NewBroker 创建Broker，buffer是每个订阅者channel的缓冲大小
+func NewBroker[T any](policy SlowPolicy, buffer int) *Broker[T] {
+	if buffer < 0 {
+		buffer = 0
+	}
+	return &Broker[T]{
+		policy: policy,
+		buffer: buffer,
+		subs:   make(map[*subscriber[T]]struct{}),
+		quit:   make(chan struct{}),
+	}
+}
+
+// Subscribe 订阅，返回接收消息的channel和取消订阅的函数
+// 取消订阅或Broker关闭后channel会被关闭；取消函数可以重复调用
+func (b *Broker[T]) Subscribe() (<-chan T, func()) {
+	sub := &subscriber[T]{
+		ch:   make(chan T, b.buffer),
+		done: make(chan struct{}),
+	}
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		close(sub.ch)
+		return sub.ch, func() {}
+	}
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+
+	return sub.ch, func() {
+		b.unsubscribe(sub)
+	}
+}
+
+func (b *Broker[T]) unsubscribe(sub *subscriber[T]) {
+	// 先关done，不拿锁，这样正阻塞在这个订阅者上的Publish能先退出并释放读锁
+	sub.once.Do(func() {
+		close(sub.done)
+	})
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[sub]; ok {
+		delete(b.subs, sub)
+		close(sub.ch)
+	}
+}
+
+// Publish 把v发给所有订阅者，慢订阅者按policy处理；Broker开始关闭后什么都不做
+func (b *Broker[T]) Publish(v T) {
+	if b.closing.Load() {
+		return
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return
+	}
+	for sub := range b.subs {
+		if b.policy == PolicyDrop {
+			select {
+			case sub.ch <- v:
+			default:
+			}
+			continue
+		}
+		select {
+		case sub.ch <- v:
+		case <-sub.done:
+		case <-b.quit:
+			return
+		}
+	}
+}
+
+// Close 不再接受新的Publish，等正在进行的Publish投递完，然后关闭所有订阅者的channel
+// ctx先结束时放弃还没投递完的消息，照样关闭所有channel，并返回ctx.Err()；可以重复调用
+func (b *Broker[T]) Close(ctx context.Context) error {
+	b.closing.Store(true)
+	// 正在投递的Publish持有读锁，拿到写锁说明它们都结束了
+	locked := make(chan struct{})
+	go func() {
+		b.mu.Lock()
+		close(locked)
+	}()
+	var err error
+	select {
+	case <-locked:
+	case <-ctx.Done():
+		err = ctx.Err()
+		b.quitOnce.Do(func() {
+			close(b.quit)
+		})
+		<-locked
+	}
+	defer b.mu.Unlock()
+	if b.closed {
+		return err
+	}
+	b.closed = true
+	for sub := range b.subs {
+		sub.once.Do(func() {
+			close(sub.done)
+		})
+		close(sub.ch)
+		delete(b.subs, sub)
+	}
+	return err
+}
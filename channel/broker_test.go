@@ -0,0 +1,180 @@
+package channel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBrokerMultipleSubscribers(t *testing.T) {
+	b := NewBroker[string](PolicyBlock, 1)
+	ch1, _ := b.Subscribe()
+	ch2, _ := b.Subscribe()
+	b.Publish("hi")
+	for i, ch := range []<-chan string{ch1, ch2} {
+		select {
+		case v := <-ch:
+			if v != "hi" {
+				t.Fatalf("subscriber %d got %q, want hi", i, v)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("subscriber %d got nothing", i)
+		}
+	}
+
+	// Close之后所有订阅者的channel都关闭
+	if err := b.Close(context.Background()); err != nil {
+		t.Fatalf("Close = %v", err)
+	}
+	for i, ch := range []<-chan string{ch1, ch2} {
+		if _, ok := <-ch; ok {
+			t.Fatalf("subscriber %d channel not closed", i)
+		}
+	}
+	// 关闭后的订阅直接拿到关闭的channel，Publish什么都不做
+	ch3, _ := b.Subscribe()
+	if _, ok := <-ch3; ok {
+		t.Fatal("Subscribe after Close returned an open channel")
+	}
+	b.Publish("late")
+}
+
+func TestBrokerUnsubscribe(t *testing.T) {
+	b := NewBroker[int](PolicyBlock, 1)
+	ch1, unsub := b.Subscribe()
+	ch2, _ := b.Subscribe()
+	unsub()
+	unsub()
+	if _, ok := <-ch1; ok {
+		t.Fatal("unsubscribed channel not closed")
+	}
+
+	b.Publish(1)
+	if v := <-ch2; v != 1 {
+		t.Fatalf("remaining subscriber got %d, want 1", v)
+	}
+}
+
+func TestBrokerDropPolicy(t *testing.T) {
+	b := NewBroker[int](PolicyDrop, 1)
+	slow, _ := b.Subscribe()
+
+	// 没人读的订阅者满了以后Publish也不能阻塞
+	done := make(chan struct{})
+	go func() {
+		for i := 1; i <= 10; i++ {
+			b.Publish(i)
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked on a full subscriber with PolicyDrop")
+	}
+	if v := <-slow; v != 1 {
+		t.Fatalf("slow subscriber got %d, want the first message", v)
+	}
+	select {
+	case v := <-slow:
+		t.Fatalf("slow subscriber got %d, want later messages dropped", v)
+	default:
+	}
+}
+
+func TestBrokerBlockPolicyUnsubscribeUnblocks(t *testing.T) {
+	b := NewBroker[int](PolicyBlock, 0)
+	_, unsub := b.Subscribe()
+	done := make(chan struct{})
+	go func() {
+		b.Publish(1)
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("Publish did not block on a subscriber with PolicyBlock")
+	case <-time.After(20 * time.Millisecond):
+	}
+	// 取消订阅让阻塞在它上面的Publish退出
+	unsub()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish still blocked after unsubscribe")
+	}
+}
+
+func TestBrokerCloseTimeout(t *testing.T) {
+	b := NewBroker[int](PolicyBlock, 0)
+	ch, _ := b.Subscribe()
+	published := make(chan struct{})
+	go func() {
+		b.Publish(1)
+		close(published)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	// 订阅者一直不读，Close在ctx结束后放弃投递
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := b.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Close = %v, want DeadlineExceeded", err)
+	}
+	<-published
+	if _, ok := <-ch; ok {
+		t.Fatal("subscriber channel not closed after Close")
+	}
+}
+
+func TestBrokerPublishAfterClose(t *testing.T) {
+	b := NewBroker[int](PolicyBlock, 1)
+	ch, unsub := b.Subscribe()
+	if err := b.Close(context.Background()); err != nil {
+		t.Fatalf("Close = %v, want nil", err)
+	}
+	// 关闭后Publish什么都不做，不能往已关闭的channel发送
+	b.Publish(1)
+	if v, ok := <-ch; ok {
+		t.Fatalf("received %d after Close", v)
+	}
+	// 关闭后取消订阅、再次Close、再订阅都是安全的
+	unsub()
+	if err := b.Close(context.Background()); err != nil {
+		t.Fatalf("second Close = %v, want nil", err)
+	}
+	late, _ := b.Subscribe()
+	if _, ok := <-late; ok {
+		t.Fatal("Subscribe after Close returned an open channel")
+	}
+}
+
+func TestBrokerCloseDrains(t *testing.T) {
+	b := NewBroker[int](PolicyBlock, 0)
+	ch, _ := b.Subscribe()
+	published := make(chan struct{})
+	go func() {
+		b.Publish(1)
+		close(published)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	closed := make(chan error, 1)
+	go func() { closed <- b.Close(context.Background()) }()
+	// 订阅者读走正在投递的消息后，Close正常返回并关闭channel
+	if v := <-ch; v != 1 {
+		t.Fatalf("received %d, want 1", v)
+	}
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Fatalf("Close = %v, want nil", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Close did not return after the publish drained")
+	}
+	<-published
+	if _, ok := <-ch; ok {
+		t.Fatal("subscriber channel not closed after Close")
+	}
+}
@@ -0,0 +1,59 @@
+package channel
+
+import (
+	"context"
+	"time"
+)
+
+// Debounce 合并突发的输入：收到值后等wait时间，期间没有新值才把最后一个值发出去
+// in关闭时如果还有没发出去的值先发出去再关闭输出；ctx取消直接关闭输出
+func Debounce[T any](ctx context.Context, in <-chan T, wait time.Duration) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		var (
+			pending    T
+			hasPending bool
+			timer      *time.Timer
+			// 没有待发送的值时为nil，select不会选中
+			timerC <-chan time.Time
+		)
+		defer func() {
+			if timer != nil {
+				timer.Stop()
+			}
+		}()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-in:
+				if !ok {
+					if hasPending {
+						select {
+						case out <- pending:
+						case <-ctx.Done():
+						}
+					}
+					return
+				}
+				pending, hasPending = v, true
+				// 每来一个新值就重新计时
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.NewTimer(wait)
+				timerC = timer.C
+			case <-timerC:
+				timerC = nil
+				hasPending = false
+				select {
+				case out <- pending:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
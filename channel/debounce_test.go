@@ -0,0 +1,50 @@
+package channel
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDebounceBurst(t *testing.T) {
+	in := make(chan int)
+	out := Debounce(context.Background(), in, 50*time.Millisecond)
+	// 5个值都在等待时间内到达，只发出最后一个
+	for i := 1; i <= 5; i++ {
+		in <- i
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case v := <-out:
+		if v != 5 {
+			t.Fatalf("Debounce emitted %d, want 5", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Debounce emitted nothing")
+	}
+	close(in)
+	if got := collect(t, out); len(got) != 0 {
+		t.Fatalf("Debounce emitted %v after the burst, want nothing", got)
+	}
+}
+
+func TestDebounceFlushOnClose(t *testing.T) {
+	// 等待时间很长，只能靠in关闭时的flush发出去
+	out := Debounce(context.Background(), gen(1, 2, 3), time.Hour)
+	if got := collect(t, out); !slices.Equal(got, []int{3}) {
+		t.Fatalf("Debounce = %v, want [3]", got)
+	}
+}
+
+func TestDebounceCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan int)
+	out := Debounce(ctx, in, time.Hour)
+	in <- 1
+	cancel()
+	// 取消后直接关闭，不发出待发送的值
+	if got := collect(t, out); len(got) != 0 {
+		t.Fatalf("Debounce emitted %v after cancel", got)
+	}
+}
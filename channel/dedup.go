@@ -0,0 +1,58 @@
+package channel
+
+import "context"
+
+// Dedup 只转发之前没出现过的值，in关闭或ctx取消后关闭输出
+// 见过的值全部记在内存里，值的种类没有上限时用DedupWindow
+func Dedup[T comparable](ctx context.Context, in <-chan T) <-chan T {
+	return dedup(ctx, in, 0)
+}
+
+// DedupWindow 同Dedup，但只记住最近转发的n个值，更早的值再次出现时会被重新转发
+// n<=0时按1处理
+func DedupWindow[T comparable](ctx context.Context, in <-chan T, n int) <-chan T {
+	if n <= 0 {
+		n = 1
+	}
+	return dedup(ctx, in, n)
+}
+
+// dedup window为0表示不限制记住的个数
+func dedup[T comparable](ctx context.Context, in <-chan T, window int) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		seen := make(map[T]struct{})
+		// 按转发顺序记录的值，超出窗口时从最早的开始忘掉
+		var order []T
+		for {
+			var v T
+			var ok bool
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok = <-in:
+				if !ok {
+					return
+				}
+			}
+			if _, dup := seen[v]; dup {
+				continue
+			}
+			seen[v] = struct{}{}
+			if window > 0 {
+				order = append(order, v)
+				if len(order) > window {
+					delete(seen, order[0])
+					order = order[1:]
+				}
+			}
+			select {
+			case out <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
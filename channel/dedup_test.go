@@ -0,0 +1,51 @@
+package channel
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestDedup(t *testing.T) {
+	out := Dedup(context.Background(), gen(1, 2, 1, 3, 2, 2, 4, 1))
+	if got, want := collect(t, out), []int{1, 2, 3, 4}; !slices.Equal(got, want) {
+		t.Fatalf("Dedup = %v, want %v", got, want)
+	}
+}
+
+func TestDedupWindow(t *testing.T) {
+	tests := []struct {
+		name   string
+		window int
+		in     []string
+		want   []string
+	}{
+		{name: "within window", window: 3, in: []string{"a", "b", "a", "c", "b"}, want: []string{"a", "b", "c"}},
+		// a被b、c挤出窗口后再出现，重新转发
+		{name: "aged out", window: 2, in: []string{"a", "b", "c", "a", "c"}, want: []string{"a", "b", "c", "a"}},
+		// 重复的值不占窗口位置
+		{name: "duplicates do not age", window: 2, in: []string{"a", "b", "b", "b", "a"}, want: []string{"a", "b"}},
+		{name: "non-positive window", window: 0, in: []string{"a", "a", "b", "a"}, want: []string{"a", "b", "a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := DedupWindow(context.Background(), gen(tt.in...), tt.window)
+			if got := collect(t, out); !slices.Equal(got, tt.want) {
+				t.Fatalf("DedupWindow = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDedupCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan int)
+	out := Dedup(ctx, in)
+	in <- 1
+	<-out
+	cancel()
+	// 取消后输出关闭，即使in一直不关闭
+	if got := collect(t, out); len(got) != 0 {
+		t.Fatalf("got %v after cancel", got)
+	}
+}
@@ -0,0 +1,29 @@
+package channel
+
+import "context"
+
+// Drain 读出ch里剩下的所有值直到ch被关闭，按读到的顺序返回
+// ch一直不关闭时会一直阻塞
+func Drain[T any](ch <-chan T) []T {
+	var out []T
+	for v := range ch {
+		out = append(out, v)
+	}
+	return out
+}
+
+// DrainContext 同Drain，但ctx结束时提前返回已经读到的值和ctx.Err()
+func DrainContext[T any](ctx context.Context, ch <-chan T) ([]T, error) {
+	var out []T
+	for {
+		select {
+		case v, ok := <-ch:
+			if !ok {
+				return out, nil
+			}
+			out = append(out, v)
+		case <-ctx.Done():
+			return out, ctx.Err()
+		}
+	}
+}
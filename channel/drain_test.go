@@ -0,0 +1,37 @@
+package channel
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestDrain(t *testing.T) {
+	if got := Drain(gen(1, 2, 3)); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Fatalf("Drain = %v, want [1 2 3]", got)
+	}
+	if got := Drain(gen[int]()); len(got) != 0 {
+		t.Fatalf("Drain of an empty channel = %v", got)
+	}
+}
+
+func TestDrainContext(t *testing.T) {
+	got, err := DrainContext(context.Background(), gen("a", "b"))
+	if err != nil || !slices.Equal(got, []string{"a", "b"}) {
+		t.Fatalf("DrainContext = %v, %v, want [a b], nil", got, err)
+	}
+
+	// 读到一半取消，返回已经读到的部分
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan int)
+	go func() {
+		ch <- 1
+		ch <- 2
+		cancel()
+	}()
+	got2, err := DrainContext(ctx, ch)
+	if !errors.Is(err, context.Canceled) || !slices.Equal(got2, []int{1, 2}) {
+		t.Fatalf("DrainContext = %v, %v, want [1 2], Canceled", got2, err)
+	}
+}
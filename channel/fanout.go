@@ -0,0 +1,40 @@
+package channel
+
+import "context"
+
+// FanOut 把in里的值按轮询依次分发到n个输出channel，每个值只会进入其中一个
+// in关闭或ctx取消后关闭所有输出；某个输出没人读时分发会阻塞在它上面，下游需要都在消费
+func FanOut[T any](ctx context.Context, in <-chan T, n int) []<-chan T {
+	if n <= 0 {
+		n = 1
+	}
+	outs := make([]chan T, n)
+	res := make([]<-chan T, n)
+	for i := range outs {
+		outs[i] = make(chan T)
+		res[i] = outs[i]
+	}
+	go func() {
+		defer func() {
+			for _, out := range outs {
+				close(out)
+			}
+		}()
+		for i := 0; ; i = (i + 1) % n {
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-in:
+				if !ok {
+					return
+				}
+				select {
+				case outs[i] <- v:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return res
+}
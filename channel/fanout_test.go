@@ -0,0 +1,67 @@
+package channel
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFanOut(t *testing.T) {
+	const total, n = 100, 4
+	vals := make([]int, total)
+	for i := range vals {
+		vals[i] = i
+	}
+	outs := FanOut(context.Background(), gen(vals...), n)
+	if len(outs) != n {
+		t.Fatalf("FanOut returned %d outputs, want %d", len(outs), n)
+	}
+
+	var wg sync.WaitGroup
+	got := make([][]int, n)
+	for i, out := range outs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for v := range out {
+				got[i] = append(got[i], v)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 每个值恰好出现在一个输出里，轮询时每路分到的数量相同
+	seen := make(map[int]int)
+	for i, vs := range got {
+		if len(vs) != total/n {
+			t.Fatalf("output %d got %d items, want %d", i, len(vs), total/n)
+		}
+		for _, v := range vs {
+			seen[v]++
+		}
+	}
+	for _, v := range vals {
+		if seen[v] != 1 {
+			t.Fatalf("value %d delivered %d times", v, seen[v])
+		}
+	}
+}
+
+func TestFanOutCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan int)
+	outs := FanOut(ctx, in, 3)
+	cancel()
+	// 输入没关，取消后所有输出也要关闭
+	for i, out := range outs {
+		select {
+		case _, ok := <-out:
+			if ok {
+				t.Fatalf("output %d received a value after cancel", i)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("output %d not closed after cancel", i)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package channel
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNoFuncs First没有传入任何函数
+var ErrNoFuncs = errors.New("channel: no functions to run")
+
+// First 并发执行所有fn，返回最先成功的结果并取消其他的
+// 全部失败时返回所有错误合并后的结果（errors.Join）
+func First[T any](ctx context.Context, fns ...func(ctx context.Context) (T, error)) (T, error) {
+	var zero T
+	if len(fns) == 0 {
+		return zero, ErrNoFuncs
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		v   T
+		err error
+	}
+	// 缓冲等于fn的个数，返回之后剩下的goroutine也能写进去退出，不会泄漏
+	results := make(chan result, len(fns))
+	for _, fn := range fns {
+		go func() {
+			v, err := fn(ctx)
+			results <- result{v, err}
+		}()
+	}
+	errs := make([]error, 0, len(fns))
+	for range fns {
+		r := <-results
+		if r.err == nil {
+			return r.v, nil
+		}
+		errs = append(errs, r.err)
+	}
+	return zero, errors.Join(errs...)
+}
@@ -0,0 +1,85 @@
+package channel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// replica 等delay之后返回v或err，ctx先结束时返回ctx.Err()并记到cancelled里
+func replica(delay time.Duration, v string, err error, cancelled chan<- string) func(context.Context) (string, error) {
+	return func(ctx context.Context) (string, error) {
+		select {
+		case <-time.After(delay):
+			return v, err
+		case <-ctx.Done():
+			if cancelled != nil {
+				cancelled <- v
+			}
+			return "", ctx.Err()
+		}
+	}
+}
+
+func TestFirstFastestWins(t *testing.T) {
+	cancelled := make(chan string, 3)
+	got, err := First(context.Background(),
+		replica(time.Second, "slow", nil, cancelled),
+		replica(time.Millisecond, "fast", nil, cancelled),
+		replica(time.Second, "slower", nil, cancelled),
+	)
+	if err != nil || got != "fast" {
+		t.Fatalf("First = %q, %v; want fast, nil", got, err)
+	}
+	// 另外两个要被取消，不能一直跑到结束
+	for i := 0; i < 2; i++ {
+		select {
+		case <-cancelled:
+		case <-time.After(500 * time.Millisecond):
+			t.Fatalf("%d of 2 slower replicas cancelled", i)
+		}
+	}
+}
+
+func TestFirstFastestFails(t *testing.T) {
+	got, err := First(context.Background(),
+		replica(time.Millisecond, "", errors.New("replica down"), nil),
+		replica(20*time.Millisecond, "ok", nil, nil),
+	)
+	if err != nil || got != "ok" {
+		t.Fatalf("First = %q, %v; want ok, nil", got, err)
+	}
+}
+
+func TestFirstAllFail(t *testing.T) {
+	errA, errB, errC := errors.New("a"), errors.New("b"), errors.New("c")
+	got, err := First(context.Background(),
+		replica(time.Millisecond, "", errA, nil),
+		replica(2*time.Millisecond, "", errB, nil),
+		replica(3*time.Millisecond, "", errC, nil),
+	)
+	if got != "" {
+		t.Fatalf("First = %q, want zero value", got)
+	}
+	for _, want := range []error{errA, errB, errC} {
+		if !errors.Is(err, want) {
+			t.Fatalf("First error %v does not include %v", err, want)
+		}
+	}
+}
+
+func TestFirstNoFuncs(t *testing.T) {
+	if _, err := First[int](context.Background()); !errors.Is(err, ErrNoFuncs) {
+		t.Fatalf("First() = %v, want ErrNoFuncs", err)
+	}
+}
+
+func TestFirstParentCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := First(ctx, replica(time.Second, "slow", nil, nil))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("First = %v, want context.Canceled", err)
+	}
+}
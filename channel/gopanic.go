@@ -0,0 +1,75 @@
+package channel
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync/atomic"
+)
+
+// PanicError Go启动的goroutine里发生的panic，带上当时的调用栈
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (p *PanicError) Error() string {
+	return fmt.Sprintf("goroutine panic: %v\n%s", p.Value, p.Stack)
+}
+
+// PanicHandler 处理Go捕获到的panic
+type PanicHandler func(p *PanicError)
+
+var (
+	panicHandler atomic.Pointer[PanicHandler]
+	// 默认handler把第一个panic放到这里，交给主goroutine重新panic
+	panics = make(chan *PanicError, 1)
+)
+
+// SetPanicHandler 替换Go使用的panic处理函数，传nil恢复默认行为
+func SetPanicHandler(h PanicHandler) {
+	if h == nil {
+		panicHandler.Store(nil)
+		return
+	}
+	panicHandler.Store(&h)
+}
+
+// Panics 默认handler会把捕获到的第一个panic发到这里，主goroutine可以这样重新panic：
+//
+//	select {
+//	case p := <-channel.Panics():
+//		panic(p)
+//	case v := <-ch:
+//		...
+//	}
+func Panics() <-chan *PanicError {
+	return panics
+}
+
+// Go 在新的goroutine里执行fn，fn panic时不会直接让进程崩溃，
+// 而是把panic的值和调用栈交给SetPanicHandler注册的handler
+// 默认handler打日志并发到Panics()，由主goroutine决定是否重新panic
+func Go(fn func()) {
+	go func() {
+		defer func() {
+			if v := recover(); v != nil {
+				handlePanic(&PanicError{Value: v, Stack: debug.Stack()})
+			}
+		}()
+		fn()
+	}()
+}
+
+func handlePanic(p *PanicError) {
+	if h := panicHandler.Load(); h != nil {
+		(*h)(p)
+		return
+	}
+	log.Printf("goroutine panic: %v\n%s", p.Value, p.Stack)
+	// 已经有一个没被取走的panic时丢弃后面的，不阻塞出问题的goroutine
+	select {
+	case panics <- p:
+	default:
+	}
+}
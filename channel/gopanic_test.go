@@ -0,0 +1,56 @@
+package channel
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGoPanicHandler(t *testing.T) {
+	got := make(chan *PanicError, 1)
+	SetPanicHandler(func(p *PanicError) { got <- p })
+	defer SetPanicHandler(nil)
+
+	Go(func() { panic("boom") })
+	select {
+	case p := <-got:
+		if p.Value != "boom" {
+			t.Fatalf("panic value = %v, want boom", p.Value)
+		}
+		// 调用栈里能看到出问题的测试函数
+		if !strings.Contains(string(p.Stack), "TestGoPanicHandler") {
+			t.Fatalf("stack does not show the panicking function:\n%s", p.Stack)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handler not called")
+	}
+}
+
+func TestGoDefaultHandler(t *testing.T) {
+	var buf bytes.Buffer
+	old := log.Writer()
+	log.SetOutput(&buf)
+	defer log.SetOutput(old)
+
+	// 默认handler打日志并交给Panics，由调用方决定是否重新panic
+	Go(func() { panic("default") })
+	select {
+	case p := <-Panics():
+		if p.Value != "default" || !strings.Contains(p.Error(), "default") {
+			t.Fatalf("panic = %v", p)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("panic not delivered to Panics()")
+	}
+	if !strings.Contains(buf.String(), "goroutine panic: default") {
+		t.Fatalf("log = %q", buf.String())
+	}
+}
+
+func TestGoNoPanic(t *testing.T) {
+	done := make(chan struct{})
+	Go(func() { close(done) })
+	<-done
+}
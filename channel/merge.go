@@ -0,0 +1,55 @@
+package channel
+
+import (
+	"context"
+	"sync"
+)
+
+// Merge 把多个输入channel合并成一个输出，所有输入都关闭且读完后才关闭输出
+// nil channel会被跳过；没有输入时返回一个已经关闭的channel
+func Merge[T any](chans ...<-chan T) <-chan T {
+	return MergeContext(context.Background(), chans...)
+}
+
+// MergeContext 和Merge一样，ctx取消后提前停止转发并关闭输出
+func MergeContext[T any](ctx context.Context, chans ...<-chan T) <-chan T {
+	out := make(chan T)
+	var wg sync.WaitGroup
+	n := 0
+	for _, ch := range chans {
+		// nil channel永远读不到数据，不跳过的话输出永远不会关闭
+		if ch == nil {
+			continue
+		}
+		n++
+		wg.Add(1)
+		go func(ch <-chan T) {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case v, ok := <-ch:
+					if !ok {
+						return
+					}
+					select {
+					case out <- v:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}(ch)
+	}
+	// 没有有效输入时直接关闭，调用方拿到的就是已关闭的channel
+	if n == 0 {
+		close(out)
+		return out
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
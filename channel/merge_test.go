@@ -0,0 +1,64 @@
+package channel
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+// gen 返回一个装好vals并已经关闭的channel，没人读完也不会留下goroutine
+func gen[T any](vals ...T) <-chan T {
+	ch := make(chan T, len(vals))
+	for _, v := range vals {
+		ch <- v
+	}
+	close(ch)
+	return ch
+}
+
+// collect 读出ch里的所有值，一秒内没有关闭算失败
+func collect[T any](t *testing.T, ch <-chan T) []T {
+	t.Helper()
+	var got []T
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case v, ok := <-ch:
+			if !ok {
+				return got
+			}
+			got = append(got, v)
+		case <-timeout:
+			t.Fatalf("channel not closed, got %v so far", got)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	got := collect(t, Merge(gen(1, 2, 3), gen(4, 5), nil, gen(6)))
+	slices.Sort(got)
+	if want := []int{1, 2, 3, 4, 5, 6}; !slices.Equal(got, want) {
+		t.Fatalf("Merge = %v, want %v", got, want)
+	}
+}
+
+func TestMergeNoInputs(t *testing.T) {
+	for _, out := range []<-chan int{Merge[int](), Merge[int](nil, nil)} {
+		if _, ok := <-out; ok {
+			t.Fatal("Merge without inputs returned an open channel")
+		}
+	}
+}
+
+func TestMergeContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	// 输入永远不关闭，只能靠ctx结束
+	never := make(chan int)
+	out := MergeContext(ctx, never, gen(1))
+	if v := <-out; v != 1 {
+		t.Fatalf("got %d, want 1", v)
+	}
+	cancel()
+	collect(t, out)
+}
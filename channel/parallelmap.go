@@ -0,0 +1,62 @@
+package channel
+
+import (
+	"context"
+	"sync"
+)
+
+// ParallelMap 用workers个goroutine并发地对in的每个元素执行fn，结果按in的顺序返回
+// 某个元素出错时取消剩下还没开始的元素，返回第一个错误；ctx取消时返回ctx.Err()
+func ParallelMap[In, Out any](ctx context.Context, in []In, workers int, fn func(In) (Out, error)) ([]Out, error) {
+	if workers <= 0 {
+		workers = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		out      = make([]Out, len(in))
+		indexes  = make(chan int)
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	for i := 0; i < min(workers, len(in)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range indexes {
+				v, err := fn(in[idx])
+				if err != nil {
+					errOnce.Do(func() {
+						firstErr = err
+						cancel()
+					})
+					continue
+				}
+				// 每个下标只有一个goroutine写，不需要加锁
+				out[idx] = v
+			}
+		}()
+	}
+
+feed:
+	for i := range in {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	// 外部ctx取消导致提前退出
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
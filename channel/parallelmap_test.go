@@ -0,0 +1,79 @@
+package channel
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParallelMapOrder(t *testing.T) {
+	in := []int{5, 4, 3, 2, 1, 0}
+	// 值越大睡得越久，完成顺序和输入顺序相反
+	out, err := ParallelMap(context.Background(), in, len(in), func(v int) (int, error) {
+		time.Sleep(time.Duration(v) * 2 * time.Millisecond)
+		return v * 10, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{50, 40, 30, 20, 10, 0}; !slices.Equal(out, want) {
+		t.Fatalf("ParallelMap = %v, want %v", out, want)
+	}
+}
+
+func TestParallelMapWorkers(t *testing.T) {
+	const workers = 3
+	var running, peak atomic.Int32
+	in := make([]int, 20)
+	_, err := ParallelMap(context.Background(), in, workers, func(int) (int, error) {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		running.Add(-1)
+		return 0, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := peak.Load(); p > workers {
+		t.Fatalf("peak concurrency = %d, want <= %d", p, workers)
+	}
+}
+
+func TestParallelMapError(t *testing.T) {
+	errBad := errors.New("bad item")
+	var calls atomic.Int32
+	in := make([]int, 100)
+	in[2] = 1
+	out, err := ParallelMap(context.Background(), in, 2, func(v int) (int, error) {
+		calls.Add(1)
+		if v == 1 {
+			return 0, errBad
+		}
+		time.Sleep(time.Millisecond)
+		return v, nil
+	})
+	if !errors.Is(err, errBad) || out != nil {
+		t.Fatalf("ParallelMap = %v, %v, want nil and the item error", out, err)
+	}
+	// 出错后剩下的元素不再处理
+	if n := calls.Load(); n >= int32(len(in)) {
+		t.Fatalf("fn called %d times, want the rest cancelled", n)
+	}
+}
+
+func TestParallelMapCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ParallelMap(ctx, []int{1, 2, 3}, 2, func(v int) (int, error) { return v, nil }); !errors.Is(err, context.Canceled) {
+		t.Fatalf("ParallelMap = %v, want Canceled", err)
+	}
+}
@@ -0,0 +1,98 @@
+package channel
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrPoolClosed Shutdown之后再Submit返回这个错误
+var ErrPoolClosed = errors.New("channel: worker pool closed")
+
+// WorkerPool 固定数量的goroutine从同一个channel里取任务执行，限制并发数
+type WorkerPool struct {
+	tasks chan func()
+	wg    sync.WaitGroup
+	once  sync.Once
+	// closing在Shutdown一开始就置上，之后的Submit直接返回ErrPoolClosed
+	closing atomic.Bool
+	// Submit持有读锁往tasks发送，关闭tasks时持有写锁，避免往已关闭的channel发送
+	mu sync.RWMutex
+}
+
+// NewWorkerPool 创建一个有workers个goroutine的池子，workers<=0时按1处理
+func NewWorkerPool(workers int) *WorkerPool {
+	if workers <= 0 {
+		workers = 1
+	}
+	p := &WorkerPool{
+		tasks: make(chan func(), workers),
+	}
+	for i := 0; i < workers; i++ {
+		p.wg.Add(1)
+		go p.worker()
+	}
+	return p
+}
+
+func (p *WorkerPool) worker() {
+	defer p.wg.Done()
+	for task := range p.tasks {
+		task()
+	}
+}
+
+// Submit 提交任务，队列满时阻塞，直到有worker空出来
+// Shutdown之后返回ErrPoolClosed，任务不会被执行
+func (p *WorkerPool) Submit(task func()) error {
+	if p.closing.Load() {
+		return ErrPoolClosed
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	// 拿读锁期间可能已经开始Shutdown，再检查一次
+	if p.closing.Load() {
+		return ErrPoolClosed
+	}
+	p.tasks <- task
+	return nil
+}
+
+// Shutdown 不再接收新任务，等已经提交的任务全部执行完，可以重复调用
+// ctx先结束则返回ctx.Err()，剩下的任务仍会在后台执行完
+func (p *WorkerPool) Shutdown(ctx context.Context) error {
+	p.closing.Store(true)
+	p.once.Do(func() {
+		// 正阻塞在发送上的Submit拿着读锁，等它们发完再关闭，不让Shutdown因此超过ctx
+		go func() {
+			p.mu.Lock()
+			close(p.tasks)
+			p.mu.Unlock()
+		}()
+	})
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// QueueLen 已提交但还没有worker取走的任务数
+func (p *WorkerPool) QueueLen() int {
+	return len(p.tasks)
+}
+
+// Stats 返回池子的实时状态，可以直接注册到server的/debug/stats
+func (p *WorkerPool) Stats() map[string]any {
+	return map[string]any{
+		"queue_depth":    len(p.tasks),
+		"queue_capacity": cap(p.tasks),
+	}
+}
@@ -0,0 +1,113 @@
+package channel
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWorkerPoolRunsAllTasks(t *testing.T) {
+	p := NewWorkerPool(4)
+	var n atomic.Int64
+	for i := 0; i < 1000; i++ {
+		if err := p.Submit(func() { n.Add(1) }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := p.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown = %v, want nil", err)
+	}
+	if got := n.Load(); got != 1000 {
+		t.Fatalf("ran %d tasks, want 1000", got)
+	}
+}
+
+func TestWorkerPoolShutdownTimeout(t *testing.T) {
+	p := NewWorkerPool(1)
+	release := make(chan struct{})
+	defer close(release)
+	p.Submit(func() { <-release })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := p.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Shutdown = %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestWorkerPoolSubmitAfterShutdown(t *testing.T) {
+	p := NewWorkerPool(2)
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown = %v, want nil", err)
+	}
+	var ran atomic.Bool
+	if err := p.Submit(func() { ran.Store(true) }); !errors.Is(err, ErrPoolClosed) {
+		t.Fatalf("Submit after Shutdown = %v, want ErrPoolClosed", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if ran.Load() {
+		t.Fatal("task submitted after Shutdown was run")
+	}
+}
+
+func TestWorkerPoolShutdownIdempotent(t *testing.T) {
+	p := NewWorkerPool(2)
+	p.Submit(func() {})
+	// 并发和重复调用Shutdown都不能panic（比如重复close channel）
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := p.Shutdown(context.Background()); err != nil {
+				t.Errorf("Shutdown = %v, want nil", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("second Shutdown = %v, want nil", err)
+	}
+}
+
+func TestWorkerPoolShutdownDrainsQueue(t *testing.T) {
+	p := NewWorkerPool(1)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var ran atomic.Int64
+	p.Submit(func() {
+		close(started)
+		<-release
+		ran.Add(1)
+	})
+	<-started
+	// worker被第一个任务占着，这个任务排在队列里
+	if err := p.Submit(func() { ran.Add(1) }); err != nil {
+		t.Fatal(err)
+	}
+	if n := p.QueueLen(); n != 1 {
+		t.Fatalf("QueueLen = %d, want 1", n)
+	}
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- p.Shutdown(context.Background()) }()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	select {
+	case err := <-shutdown:
+		if err != nil {
+			t.Fatalf("Shutdown = %v, want nil", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Shutdown did not return")
+	}
+	// 关闭前已经排队的任务也要执行完
+	if n := ran.Load(); n != 2 {
+		t.Fatalf("ran %d tasks, want 2", n)
+	}
+}
@@ -0,0 +1,84 @@
+package channel
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrQueueClosed 队列关闭后Put返回这个错误，Get在剩余元素取完后也返回它
+var ErrQueueClosed = errors.New("channel: queue closed")
+
+// BoundedQueue 有容量上限的队列，满了Put阻塞，空了Get阻塞，都可以用ctx取消
+type BoundedQueue[T any] struct {
+	items chan T
+	// closed关闭表示队列已经Close；items本身不关闭，避免Put时往已关闭的channel发送导致panic
+	closed chan struct{}
+	once   sync.Once
+}
+
+// NewBoundedQueue 创建容量为cap的队列，cap<=0时按1处理
+func NewBoundedQueue[T any](cap int) *BoundedQueue[T] {
+	if cap <= 0 {
+		cap = 1
+	}
+	return &BoundedQueue[T]{
+		items:  make(chan T, cap),
+		closed: make(chan struct{}),
+	}
+}
+
+// Put 放入一个元素，队列满时阻塞
+// ctx结束返回ctx.Err()，队列已关闭返回ErrQueueClosed
+func (q *BoundedQueue[T]) Put(ctx context.Context, v T) error {
+	select {
+	case <-q.closed:
+		return ErrQueueClosed
+	default:
+	}
+	select {
+	case q.items <- v:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-q.closed:
+		return ErrQueueClosed
+	}
+}
+
+// Get 取出一个元素，队列空时阻塞
+// 关闭后仍然可以取出剩下的元素，取完后返回ErrQueueClosed
+func (q *BoundedQueue[T]) Get(ctx context.Context) (T, error) {
+	var zero T
+	select {
+	case v := <-q.items:
+		return v, nil
+	default:
+	}
+	select {
+	case v := <-q.items:
+		return v, nil
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	case <-q.closed:
+		// 关闭的同时可能还有剩余元素，再取一次
+		select {
+		case v := <-q.items:
+			return v, nil
+		default:
+			return zero, ErrQueueClosed
+		}
+	}
+}
+
+// Len 当前队列里的元素个数
+func (q *BoundedQueue[T]) Len() int {
+	return len(q.items)
+}
+
+// Close 关闭队列，之后不能再Put，可以重复调用
+func (q *BoundedQueue[T]) Close() {
+	q.once.Do(func() {
+		close(q.closed)
+	})
+}
@@ -0,0 +1,109 @@
+package channel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBoundedQueuePutBlocksWhenFull(t *testing.T) {
+	q := NewBoundedQueue[int](2)
+	ctx := context.Background()
+	for i := 1; i <= 2; i++ {
+		if err := q.Put(ctx, i); err != nil {
+			t.Fatalf("Put(%d) = %v", i, err)
+		}
+	}
+
+	put := make(chan error, 1)
+	go func() { put <- q.Put(ctx, 3) }()
+	select {
+	case err := <-put:
+		t.Fatalf("Put on a full queue returned %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// 取走一个之后阻塞的Put才能放进去
+	if v, err := q.Get(ctx); err != nil || v != 1 {
+		t.Fatalf("Get = %d, %v, want 1", v, err)
+	}
+	select {
+	case err := <-put:
+		if err != nil {
+			t.Fatalf("Put = %v, want nil", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Put not unblocked by Get")
+	}
+	if n := q.Len(); n != 2 {
+		t.Fatalf("Len = %d, want 2", n)
+	}
+}
+
+func TestBoundedQueueDrainAfterClose(t *testing.T) {
+	q := NewBoundedQueue[string](3)
+	ctx := context.Background()
+	for _, v := range []string{"a", "b"} {
+		if err := q.Put(ctx, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	q.Close()
+	q.Close()
+
+	if err := q.Put(ctx, "c"); !errors.Is(err, ErrQueueClosed) {
+		t.Fatalf("Put after Close = %v, want ErrQueueClosed", err)
+	}
+	// 关闭前放进去的元素仍然按顺序取出来
+	for _, want := range []string{"a", "b"} {
+		if v, err := q.Get(ctx); err != nil || v != want {
+			t.Fatalf("Get = %q, %v, want %q", v, err, want)
+		}
+	}
+	if _, err := q.Get(ctx); !errors.Is(err, ErrQueueClosed) {
+		t.Fatalf("Get on a drained queue = %v, want ErrQueueClosed", err)
+	}
+}
+
+func TestBoundedQueueCloseWakesGet(t *testing.T) {
+	q := NewBoundedQueue[int](1)
+	got := make(chan error, 1)
+	go func() {
+		_, err := q.Get(context.Background())
+		got <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	q.Close()
+	select {
+	case err := <-got:
+		if !errors.Is(err, ErrQueueClosed) {
+			t.Fatalf("Get = %v, want ErrQueueClosed", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Get not woken by Close")
+	}
+}
+
+func TestBoundedQueueContext(t *testing.T) {
+	q := NewBoundedQueue[int](1)
+	if err := q.Put(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	// 队列满时Put在ctx结束后返回
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := q.Put(ctx, 2); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Put on a full queue = %v, want DeadlineExceeded", err)
+	}
+
+	// 队列空时Get同样在ctx结束后返回
+	if _, err := q.Get(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if _, err := q.Get(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Get on an empty queue = %v, want Canceled", err)
+	}
+}
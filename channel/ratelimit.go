@@ -0,0 +1,182 @@
+package channel
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrLimiterClosed Close之后再调用Wait返回这个错误
+var ErrLimiterClosed = errors.New("channel: rate limiter closed")
+
+// RateLimiter 令牌桶限流，令牌放在带缓冲的channel里，ticker定时补充
+// NewLazyRateLimiter创建的限流器没有ticker，在Allow和Wait时按流逝的时间补充
+type RateLimiter struct {
+	tokens chan struct{}
+	ticker *time.Ticker
+	done   chan struct{}
+	once   sync.Once
+
+	// 以下字段只有lazy模式使用
+	interval time.Duration
+	now      func() time.Time
+	mu       sync.Mutex
+	// 已经补到了哪个时间点，总是比上次补充时的now早不到一个interval
+	last time.Time
+}
+
+// NewRateLimiter 每per时间内最多放行rate次，桶的容量也是rate，初始为满
+func NewRateLimiter(rate int, per time.Duration) *RateLimiter {
+	if rate <= 0 {
+		rate = 1
+	}
+	return NewBurstRateLimiter(per/time.Duration(rate), rate)
+}
+
+// NewBurstRateLimiter 每隔interval补充一个令牌，桶的容量为burst，初始为满
+func NewBurstRateLimiter(interval time.Duration, burst int) *RateLimiter {
+	if burst <= 0 {
+		burst = 1
+	}
+	if interval <= 0 {
+		interval = 1
+	}
+	l := &RateLimiter{
+		tokens: make(chan struct{}, burst),
+		ticker: time.NewTicker(interval),
+		done:   make(chan struct{}),
+	}
+	for i := 0; i < burst; i++ {
+		l.tokens <- struct{}{}
+	}
+	go l.refill()
+	return l
+}
+
+// NewLazyRateLimiter 和NewBurstRateLimiter一样每隔interval补充一个令牌，桶的容量为burst，初始为满
+// 但不起goroutine也不用ticker，适合数量很多、大部分时间空闲的限流器，比如每个客户端一个
+func NewLazyRateLimiter(interval time.Duration, burst int) *RateLimiter {
+	if burst <= 0 {
+		burst = 1
+	}
+	if interval <= 0 {
+		interval = 1
+	}
+	l := &RateLimiter{
+		tokens:   make(chan struct{}, burst),
+		done:     make(chan struct{}),
+		interval: interval,
+		now:      time.Now,
+	}
+	l.last = l.now()
+	for i := 0; i < burst; i++ {
+		l.tokens <- struct{}{}
+	}
+	return l
+}
+
+func (l *RateLimiter) refill() {
+	for {
+		select {
+		case <-l.done:
+			return
+		case <-l.ticker.C:
+			// 桶满了就丢掉这个令牌
+			select {
+			case l.tokens <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// catchUp lazy模式下补上从last到现在应该放进桶里的令牌
+func (l *RateLimiter) catchUp() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := l.now().Sub(l.last) / l.interval
+	if n <= 0 {
+		return
+	}
+	// 和ticker一样按固定的节拍补充，桶满时多出来的令牌丢掉
+	l.last = l.last.Add(n * l.interval)
+	for room := cap(l.tokens) - len(l.tokens); n > 0 && room > 0; n, room = n-1, room-1 {
+		select {
+		case l.tokens <- struct{}{}:
+		default:
+			return
+		}
+	}
+}
+
+// untilNext lazy模式下距离补充下一个令牌还要多久
+func (l *RateLimiter) untilNext() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.last.Add(l.interval).Sub(l.now())
+}
+
+// Allow 不阻塞，有令牌时拿走并返回true
+func (l *RateLimiter) Allow() bool {
+	if l.ticker == nil {
+		l.catchUp()
+	}
+	select {
+	case <-l.tokens:
+		return true
+	default:
+		return false
+	}
+}
+
+// Wait 阻塞直到拿到令牌，ctx结束返回ctx.Err()，限流器关闭返回ErrLimiterClosed
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l.ticker == nil {
+		return l.waitLazy(ctx)
+	}
+	select {
+	case <-l.tokens:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-l.done:
+		return ErrLimiterClosed
+	}
+}
+
+// waitLazy 没有goroutine补充令牌，拿不到时睡到下一个令牌补充的时间再试
+func (l *RateLimiter) waitLazy(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-l.done:
+			return ErrLimiterClosed
+		default:
+		}
+		if l.Allow() {
+			return nil
+		}
+		t := time.NewTimer(l.untilNext())
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-l.done:
+			t.Stop()
+			return ErrLimiterClosed
+		}
+	}
+}
+
+// Close 停止ticker和补充令牌的goroutine，可以重复调用；lazy模式下只是让Wait返回ErrLimiterClosed
+func (l *RateLimiter) Close() {
+	l.once.Do(func() {
+		if l.ticker != nil {
+			l.ticker.Stop()
+		}
+		close(l.done)
+	})
+}
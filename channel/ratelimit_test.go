@@ -0,0 +1,137 @@
+package channel
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	l := NewRateLimiter(10, time.Second)
+	defer l.Close()
+	for i := 0; i < 10; i++ {
+		if !l.Allow() {
+			t.Fatalf("Allow #%d = false, want true", i+1)
+		}
+	}
+	if l.Allow() {
+		t.Fatal("Allow #11 = true, want false")
+	}
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	l := NewRateLimiter(1, time.Hour)
+	defer l.Close()
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait = %v", err)
+	}
+	// 桶已经空了，下一个令牌要一小时后才有
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Wait = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestRateLimiterRefill(t *testing.T) {
+	l := NewBurstRateLimiter(time.Millisecond, 1)
+	defer l.Close()
+	l.Allow()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := l.Wait(ctx); err != nil {
+		t.Fatalf("Wait = %v, token not refilled", err)
+	}
+}
+
+func TestRateLimiterClose(t *testing.T) {
+	l := NewRateLimiter(1, time.Hour)
+	l.Allow()
+	l.Close()
+	l.Close()
+	if err := l.Wait(context.Background()); err != ErrLimiterClosed {
+		t.Fatalf("Wait after Close = %v, want ErrLimiterClosed", err)
+	}
+}
+
+// lazyClock 把lazy限流器的时间换成测试里手动推进的时间
+func lazyClock(l *RateLimiter) *time.Time {
+	now := l.last
+	l.now = func() time.Time { return now }
+	return &now
+}
+
+func TestLazyRateLimiterRefill(t *testing.T) {
+	l := NewLazyRateLimiter(500*time.Millisecond, 3)
+	defer l.Close()
+	now := lazyClock(l)
+	for i := 0; i < 3; i++ {
+		if !l.Allow() {
+			t.Fatalf("Allow #%d within burst = false", i+1)
+		}
+	}
+	if l.Allow() {
+		t.Fatal("Allow over burst = true")
+	}
+	// 每500ms补一个
+	*now = now.Add(700 * time.Millisecond)
+	if !l.Allow() || l.Allow() {
+		t.Fatal("want exactly one token after 700ms")
+	}
+	// 不足一个interval的300ms没有丢，再过200ms就补下一个
+	*now = now.Add(300 * time.Millisecond)
+	if !l.Allow() {
+		t.Fatal("token not refilled on the fixed beat")
+	}
+	// 空闲再久也最多攒burst个
+	*now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		if !l.Allow() {
+			t.Fatalf("Allow #%d after idle = false", i+1)
+		}
+	}
+	if l.Allow() {
+		t.Fatal("tokens accumulated beyond burst")
+	}
+}
+
+func TestLazyRateLimiterWait(t *testing.T) {
+	l := NewLazyRateLimiter(time.Millisecond, 1)
+	defer l.Close()
+	l.Allow()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := l.Wait(ctx); err != nil {
+		t.Fatalf("Wait = %v, token not refilled", err)
+	}
+
+	slow := NewLazyRateLimiter(time.Hour, 1)
+	slow.Allow()
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := slow.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Wait = %v, want context.DeadlineExceeded", err)
+	}
+	// Close让阻塞的Wait返回
+	errc := make(chan error, 1)
+	go func() { errc <- slow.Wait(context.Background()) }()
+	slow.Close()
+	slow.Close()
+	if err := <-errc; err != ErrLimiterClosed {
+		t.Fatalf("Wait after Close = %v, want ErrLimiterClosed", err)
+	}
+}
+
+func TestLazyRateLimiterNoGoroutines(t *testing.T) {
+	before := runtime.NumGoroutine()
+	// interval很小也不会起ticker空转，也不会一个一个地补很久
+	ls := make([]*RateLimiter, 1000)
+	for i := range ls {
+		ls[i] = NewLazyRateLimiter(time.Nanosecond, 10)
+		ls[i].Allow()
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Fatalf("goroutines = %d after 1000 limiters, want <= %d", n, before)
+	}
+}
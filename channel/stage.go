@@ -0,0 +1,61 @@
+package channel
+
+import "context"
+
+// StageOption 修改Stage的行为
+type StageOption func(*stageConfig)
+
+type stageConfig struct {
+	stopOnError bool
+}
+
+// StageStopOnError 出现第一个错误后停止处理，关闭输出让下游也结束
+// 默认遇到错误只是发到错误channel，继续处理后面的输入
+func StageStopOnError(c *stageConfig) {
+	c.stopOnError = true
+}
+
+// Stage 对in里的每个值执行fn，结果按输入顺序发到第一个channel，错误发到第二个channel
+// in关闭或ctx取消后两个channel都会关闭，调用方需要同时读取两个channel，否则stage会阻塞
+func Stage[In, Out any](ctx context.Context, in <-chan In, fn func(In) (Out, error), opts ...StageOption) (<-chan Out, <-chan error) {
+	var cfg stageConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	out := make(chan Out)
+	errs := make(chan error)
+	go func() {
+		defer close(out)
+		defer close(errs)
+		for {
+			var v In
+			var ok bool
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok = <-in:
+				if !ok {
+					return
+				}
+			}
+			res, err := fn(v)
+			if err != nil {
+				select {
+				case errs <- err:
+				case <-ctx.Done():
+					return
+				}
+				if cfg.stopOnError {
+					return
+				}
+				continue
+			}
+			select {
+			case out <- res:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, errs
+}
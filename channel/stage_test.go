@@ -0,0 +1,78 @@
+package channel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// errsOf 在后台读完errs，Stage要求错误channel也有人读
+func errsOf(errs <-chan error) <-chan []error {
+	res := make(chan []error, 1)
+	go func() {
+		var all []error
+		for err := range errs {
+			all = append(all, err)
+		}
+		res <- all
+	}()
+	return res
+}
+
+var errOdd = errors.New("odd")
+
+func rejectOdd(v int) (int, error) {
+	if v%2 != 0 {
+		return 0, fmt.Errorf("%d: %w", v, errOdd)
+	}
+	return v, nil
+}
+
+func TestStageChain(t *testing.T) {
+	ctx := context.Background()
+	doubled, errs1 := Stage(ctx, gen(1, 2, 3, 4), func(v int) (int, error) { return v * 2, nil })
+	strs, errs2 := Stage(ctx, doubled, func(v int) (string, error) { return strconv.Itoa(v), nil })
+	e1, e2 := errsOf(errs1), errsOf(errs2)
+
+	got := collect(t, strs)
+	if want := []string{"2", "4", "6", "8"}; !slices.Equal(got, want) {
+		t.Fatalf("output = %v, want %v", got, want)
+	}
+	if errs := append(<-e1, <-e2...); len(errs) != 0 {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+}
+
+func TestStageContinuesOnError(t *testing.T) {
+	out, errs := Stage(context.Background(), gen(1, 2, 3, 4), rejectOdd)
+	e := errsOf(errs)
+	if got, want := collect(t, out), []int{2, 4}; !slices.Equal(got, want) {
+		t.Fatalf("output = %v, want %v", got, want)
+	}
+	if errs := <-e; len(errs) != 2 || !errors.Is(errs[0], errOdd) {
+		t.Fatalf("errors = %v, want two odd errors", errs)
+	}
+}
+
+func TestStageStopOnError(t *testing.T) {
+	out, errs := Stage(context.Background(), gen(2, 3, 4, 5), rejectOdd, StageStopOnError)
+	e := errsOf(errs)
+	if got, want := collect(t, out), []int{2}; !slices.Equal(got, want) {
+		t.Fatalf("output = %v, want %v", got, want)
+	}
+	if errs := <-e; len(errs) != 1 {
+		t.Fatalf("errors = %v, want exactly the first one", errs)
+	}
+}
+
+func TestStageContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	out, errs := Stage(ctx, make(chan int), rejectOdd)
+	e := errsOf(errs)
+	collect(t, out)
+	<-e
+}
@@ -0,0 +1,76 @@
+package channel
+
+import (
+	"context"
+	"time"
+)
+
+// ThrottleOption 修改ThrottleWrite的行为
+type ThrottleOption func(*throttleConfig)
+
+type throttleConfig struct {
+	keepLatest bool
+}
+
+// ThrottleKeepLatest 输入比输出快时丢掉中间的值，只保留最新的一个
+// 默认把所有值都缓存下来，按间隔依次发出
+func ThrottleKeepLatest(c *throttleConfig) {
+	c.keepLatest = true
+}
+
+// ThrottleWrite 转发in里的值，保证两次输出之间至少间隔minInterval
+// in关闭后把缓存的值按间隔发完再关闭输出；ctx取消直接关闭输出，缓存的值丢弃
+func ThrottleWrite[T any](ctx context.Context, in <-chan T, minInterval time.Duration, opts ...ThrottleOption) <-chan T {
+	var cfg throttleConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		var (
+			pending []T
+			ready   = true
+			timer   *time.Timer
+			// 间隔没到时为nil，select不会选中
+			timerC <-chan time.Time
+		)
+		defer func() {
+			if timer != nil {
+				timer.Stop()
+			}
+		}()
+		for in != nil || len(pending) > 0 {
+			// 间隔到了并且有值时才打开发送分支
+			var sendC chan<- T
+			var next T
+			if ready && len(pending) > 0 {
+				sendC = out
+				next = pending[0]
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-in:
+				if !ok {
+					in = nil
+					continue
+				}
+				if cfg.keepLatest {
+					pending = append(pending[:0], v)
+				} else {
+					pending = append(pending, v)
+				}
+			case sendC <- next:
+				pending = pending[1:]
+				ready = false
+				timer = time.NewTimer(minInterval)
+				timerC = timer.C
+			case <-timerC:
+				ready = true
+				timerC = nil
+			}
+		}
+	}()
+	return out
+}
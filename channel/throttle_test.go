@@ -0,0 +1,72 @@
+package channel
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestThrottleWriteSpacing(t *testing.T) {
+	const interval = 20 * time.Millisecond
+	out := ThrottleWrite(context.Background(), gen(1, 2, 3, 4, 5), interval)
+
+	var got []int
+	var stamps []time.Time
+	timeout := time.After(time.Second)
+	for done := false; !done; {
+		select {
+		case v, ok := <-out:
+			if !ok {
+				done = true
+				break
+			}
+			got = append(got, v)
+			stamps = append(stamps, time.Now())
+		case <-timeout:
+			t.Fatalf("output not closed, got %v so far", got)
+		}
+	}
+	// 默认策略不丢值，按顺序全部发出
+	if want := []int{1, 2, 3, 4, 5}; !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	// 接收方记时间有一点误差，留10%的余量
+	for i := 1; i < len(stamps); i++ {
+		if gap := stamps[i].Sub(stamps[i-1]); gap < interval*9/10 {
+			t.Fatalf("gap between output %d and %d = %v, want >= %v", i-1, i, gap, interval)
+		}
+	}
+}
+
+func TestThrottleWriteKeepLatest(t *testing.T) {
+	in := make(chan int)
+	out := ThrottleWrite(context.Background(), in, 100*time.Millisecond, ThrottleKeepLatest)
+
+	in <- 1
+	if v := <-out; v != 1 {
+		t.Fatalf("first output = %d, want 1", v)
+	}
+	// 间隔还没到，这些值只有最后一个会发出
+	for i := 2; i <= 10; i++ {
+		in <- i
+	}
+	close(in)
+	if got, want := collect(t, out), []int{10}; !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestThrottleWriteCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan int)
+	out := ThrottleWrite(ctx, in, time.Hour)
+	in <- 1
+	<-out
+	in <- 2
+	// 第二个值要等一小时，取消后输出直接关闭
+	cancel()
+	if got := collect(t, out); len(got) != 0 {
+		t.Fatalf("got %v after cancel, want nothing", got)
+	}
+}
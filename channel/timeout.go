@@ -0,0 +1,17 @@
+package channel
+
+import "time"
+
+// RecvTimeout 在d时间内从ch收到值时返回该值和true，超时或ch已关闭返回零值和false
+// 返回前会停止timer，大量调用也不会堆积没到期的timer
+func RecvTimeout[T any](ch <-chan T, d time.Duration) (T, bool) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case v, ok := <-ch:
+		return v, ok
+	case <-timer.C:
+		var zero T
+		return zero, false
+	}
+}
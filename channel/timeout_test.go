@@ -0,0 +1,58 @@
+package channel
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestRecvTimeout(t *testing.T) {
+	ready := make(chan int, 1)
+	ready <- 7
+	closed := make(chan int)
+	close(closed)
+	tests := []struct {
+		name   string
+		ch     <-chan int
+		want   int
+		wantOK bool
+		// 只有一直收不到值时才会等满d
+		wait bool
+	}{
+		{name: "delivers", ch: ready, want: 7, wantOK: true},
+		{name: "never delivers", ch: make(chan int), wait: true},
+		{name: "closed", ch: closed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const d = 20 * time.Millisecond
+			start := time.Now()
+			v, ok := RecvTimeout(tt.ch, d)
+			elapsed := time.Since(start)
+			if v != tt.want || ok != tt.wantOK {
+				t.Fatalf("RecvTimeout = %d, %v; want %d, %v", v, ok, tt.want, tt.wantOK)
+			}
+			if waited := elapsed >= d; waited != tt.wait {
+				t.Fatalf("returned after %v with d = %v", elapsed, d)
+			}
+		})
+	}
+}
+
+func TestRecvTimeoutNoLeak(t *testing.T) {
+	before := runtime.NumGoroutine()
+	ch := make(chan int, 1)
+	for i := 0; i < 10000; i++ {
+		ch <- i
+		// 值先到，timer要在返回前停掉
+		if v, ok := RecvTimeout(ch, time.Hour); !ok || v != i {
+			t.Fatalf("RecvTimeout = %d, %v; want %d, true", v, ok, i)
+		}
+	}
+	for i := 0; i < 100; i++ {
+		RecvTimeout(make(chan int), time.Microsecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Fatalf("goroutines grew from %d to %d", before, after)
+	}
+}
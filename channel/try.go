@@ -0,0 +1,23 @@
+package channel
+
+// TrySend 接收方就绪或缓冲区有空位时发送v并返回true，否则立即返回false
+// 和普通发送一样，往已关闭的channel发送会panic
+func TrySend[T any](ch chan<- T, v T) bool {
+	select {
+	case ch <- v:
+		return true
+	default:
+		return false
+	}
+}
+
+// TryRecv 有值可取时返回该值和true；没有值或channel已关闭时立即返回零值和false
+func TryRecv[T any](ch <-chan T) (T, bool) {
+	select {
+	case v, ok := <-ch:
+		return v, ok
+	default:
+		var zero T
+		return zero, false
+	}
+}
@@ -0,0 +1,28 @@
+package channel
+
+import "testing"
+
+func TestTrySendTryRecv(t *testing.T) {
+	ch := make(chan int, 1)
+	if _, ok := TryRecv(ch); ok {
+		t.Fatal("TryRecv on an empty channel succeeded")
+	}
+	if !TrySend(ch, 1) {
+		t.Fatal("TrySend with buffer space failed")
+	}
+	if TrySend(ch, 2) {
+		t.Fatal("TrySend on a full channel succeeded")
+	}
+	if v, ok := TryRecv(ch); !ok || v != 1 {
+		t.Fatalf("TryRecv = %d, %v, want 1, true", v, ok)
+	}
+
+	// 无缓冲channel没有接收方时发送失败
+	if TrySend(make(chan int), 1) {
+		t.Fatal("TrySend without a receiver succeeded")
+	}
+	close(ch)
+	if v, ok := TryRecv(ch); ok || v != 0 {
+		t.Fatalf("TryRecv on a closed channel = %d, %v, want 0, false", v, ok)
+	}
+}
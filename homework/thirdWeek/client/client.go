@@ -0,0 +1,174 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"gostudy/retry"
+)
+
+// ErrCircuitOpen 熔断期间请求直接失败，不会发出去
+var ErrCircuitOpen = errors.New("client: circuit breaker is open")
+
+const (
+	defaultMaxRetries       = 3
+	defaultBaseBackoff      = 100 * time.Millisecond
+	defaultMaxBackoff       = 2 * time.Second
+	defaultFailureThreshold = 5
+	defaultCooldown         = 10 * time.Second
+)
+
+// Option 用来修改Client的配置
+type Option func(*Client)
+
+// WithHTTPClient 使用自定义的http.Client
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.hc = hc
+	}
+}
+
+// WithMaxRetries 幂等请求失败后最多重试n次
+func WithMaxRetries(n int) Option {
+	return func(c *Client) {
+		c.maxRetries = n
+	}
+}
+
+// WithBackoff 重试间隔从base开始指数增长，最多到max
+func WithBackoff(base, max time.Duration) Option {
+	return func(c *Client) {
+		c.baseBackoff = base
+		c.maxBackoff = max
+	}
+}
+
+// WithBreaker 连续失败threshold次后熔断，cooldown之后再试探
+func WithBreaker(threshold int, cooldown time.Duration) Option {
+	return func(c *Client) {
+		c.breaker = newBreaker(threshold, cooldown)
+	}
+}
+
+// Client 包装http.Client，幂等请求遇到网络错误或5xx时重试，连续失败后熔断
+type Client struct {
+	hc          *http.Client
+	maxRetries  int
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+	breaker     *breaker
+}
+
+// NewClient 创建Client
+func NewClient(opts ...Option) *Client {
+	c := &Client{
+		hc:          http.DefaultClient,
+		maxRetries:  defaultMaxRetries,
+		baseBackoff: defaultBaseBackoff,
+		maxBackoff:  defaultMaxBackoff,
+		breaker:     newBreaker(defaultFailureThreshold, defaultCooldown),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Do 发送请求
+// 只有幂等请求才会重试；熔断期间返回ErrCircuitOpen；重试用完后最后一次的5xx响应原样返回
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	attempts := 1
+	if isIdempotent(req) && c.maxRetries > 0 {
+		attempts += c.maxRetries
+	}
+	ctx := req.Context()
+	backoff := retry.NewExponentialBackoff(c.baseBackoff, c.maxBackoff, 2)
+	for i := 0; ; i++ {
+		if i > 0 {
+			// 重试需要重新生成body，在问熔断器之前做，失败了不占用半开的试探名额
+			if err := rewindBody(req); err != nil {
+				return nil, err
+			}
+		}
+		if !c.breaker.allow() {
+			return nil, ErrCircuitOpen
+		}
+		resp, err := c.send(req)
+		if succeeded(resp, err) {
+			return resp, nil
+		}
+		if i == attempts-1 || !canRetry(req) {
+			return resp, err
+		}
+		if resp != nil {
+			// 读完再关闭，连接才能复用
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if err := sleepContext(ctx, backoff.Next()); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// send 发一次请求，结果记到熔断器上
+// 熔断器放行之后必须走这里：每条路径都要记录一次结果（hc.Do panic也算失败），
+// 否则半开状态的熔断器等不到试探结果，会一直拒绝后面的请求
+func (c *Client) send(req *http.Request) (resp *http.Response, err error) {
+	ok := false
+	defer func() {
+		if ok {
+			c.breaker.success()
+		} else {
+			c.breaker.failure()
+		}
+	}()
+	resp, err = c.hc.Do(req)
+	ok = succeeded(resp, err)
+	return resp, err
+}
+
+// succeeded 没有网络错误并且不是5xx
+func succeeded(resp *http.Response, err error) bool {
+	return err == nil && resp.StatusCode < http.StatusInternalServerError
+}
+
+// isIdempotent 按方法判断，非幂等方法带了Idempotency-Key也可以重试
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+// canRetry 有body但没法重新生成的请求不能重试
+func canRetry(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+func rewindBody(req *http.Request) error {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return err
+	}
+	req.Body = body
+	return nil
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,157 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer 前failures个请求返回500，之后返回200，hits记录收到的请求数
+func flakyServer(t *testing.T, failures int64) (url string, hits *atomic.Int64) {
+	t.Helper()
+	hits = new(atomic.Int64)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if hits.Add(1) <= failures {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL, hits
+}
+
+// fastClient 重试间隔调到1ms
+func fastClient(opts ...Option) *Client {
+	return NewClient(append([]Option{WithBackoff(time.Millisecond, time.Millisecond)}, opts...)...)
+}
+
+func do(t *testing.T, c *Client, method, url string) (int, error) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+func TestClientRetry(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		failures   int64
+		maxRetries int
+		wantCode   int
+		wantHits   int64
+	}{
+		{name: "recovers", method: http.MethodGet, failures: 2, maxRetries: 3, wantCode: http.StatusOK, wantHits: 3},
+		{name: "exhausted", method: http.MethodGet, failures: 10, maxRetries: 2, wantCode: http.StatusInternalServerError, wantHits: 3},
+		{name: "not idempotent", method: http.MethodPost, failures: 10, maxRetries: 3, wantCode: http.StatusInternalServerError, wantHits: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url, hits := flakyServer(t, tt.failures)
+			c := fastClient(WithMaxRetries(tt.maxRetries), WithBreaker(100, time.Hour))
+			code, err := do(t, c, tt.method, url)
+			if err != nil || code != tt.wantCode {
+				t.Fatalf("Do = %d, %v, want %d", code, err, tt.wantCode)
+			}
+			if n := hits.Load(); n != tt.wantHits {
+				t.Fatalf("server got %d requests, want %d", n, tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestClientBreakerOpens(t *testing.T) {
+	url, hits := flakyServer(t, 100)
+	c := fastClient(WithMaxRetries(0), WithBreaker(3, time.Hour))
+	for i := 0; i < 3; i++ {
+		if code, err := do(t, c, http.MethodGet, url); err != nil || code != http.StatusInternalServerError {
+			t.Fatalf("request %d = %d, %v, want 500", i, code, err)
+		}
+	}
+	// 熔断之后直接失败，请求不会发到服务端
+	for i := 0; i < 3; i++ {
+		if _, err := do(t, c, http.MethodGet, url); !errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("Do while open = %v, want ErrCircuitOpen", err)
+		}
+	}
+	if n := hits.Load(); n != 3 {
+		t.Fatalf("server got %d requests, want 3", n)
+	}
+}
+
+func TestClientBreakerHalfOpen(t *testing.T) {
+	url, hits := flakyServer(t, 1)
+	c := fastClient(WithMaxRetries(0), WithBreaker(1, time.Minute))
+	now := time.Now()
+	c.breaker.now = func() time.Time { return now }
+
+	if code, _ := do(t, c, http.MethodGet, url); code != http.StatusInternalServerError {
+		t.Fatalf("first request = %d, want 500", code)
+	}
+	if _, err := do(t, c, http.MethodGet, url); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Do during cooldown = %v, want ErrCircuitOpen", err)
+	}
+	// 冷却结束后放一个试探请求，成功后恢复正常
+	now = now.Add(time.Minute)
+	for i := 0; i < 2; i++ {
+		if code, err := do(t, c, http.MethodGet, url); err != nil || code != http.StatusOK {
+			t.Fatalf("request %d after cooldown = %d, %v, want 200", i, code, err)
+		}
+	}
+	if n := hits.Load(); n != 3 {
+		t.Fatalf("server got %d requests, want 3", n)
+	}
+}
+
+func TestClientRewindFailureKeepsBreakerUsable(t *testing.T) {
+	url, _ := flakyServer(t, 1)
+	// 冷却为0，第一次失败熔断后重试时熔断器马上就会半开
+	c := fastClient(WithMaxRetries(1), WithBreaker(1, 0))
+	errGetBody := errors.New("body gone")
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Idempotency-Key", "k1")
+	req.GetBody = func() (io.ReadCloser, error) { return nil, errGetBody }
+	if _, err := c.Do(req); !errors.Is(err, errGetBody) {
+		t.Fatalf("Do = %v, want the GetBody error", err)
+	}
+	// 重新生成body失败不能让熔断器卡在半开
+	if code, err := do(t, c, http.MethodGet, url); err != nil || code != http.StatusOK {
+		t.Fatalf("Do after rewind failure = %d, %v, want 200", code, err)
+	}
+}
+
+func TestClientTransportPanicRecordsFailure(t *testing.T) {
+	c := fastClient(WithMaxRetries(0), WithBreaker(1, time.Hour), WithHTTPClient(&http.Client{
+		Transport: roundTripFunc(func(*http.Request) (*http.Response, error) { panic("transport") }),
+	}))
+	func() {
+		defer func() { recover() }()
+		do(t, c, http.MethodGet, "http://example.invalid")
+	}()
+	// panic也记了一次失败，阈值为1时熔断
+	if _, err := do(t, c, http.MethodGet, "http://example.invalid"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Do after panic = %v, want ErrCircuitOpen", err)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
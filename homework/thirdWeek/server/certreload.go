@@ -0,0 +1,121 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CertReloader 缓存当前使用的证书，证书文件变化后重新加载，不需要重启服务
+// 通过tls.Config.GetCertificate接入，新证书无效时继续使用旧证书
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	cert     atomic.Pointer[tls.Certificate]
+
+	// 保证同一时间只有一个Reload，同时保护modTime
+	mu      sync.Mutex
+	modTime time.Time
+}
+
+// NewCertReloader 加载一次证书，失败时返回错误
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	r := &CertReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload 重新读取证书和私钥，校验通过后替换当前证书
+// 出错时保留原来的证书，返回错误
+func (r *CertReloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return err
+	}
+	cert, err := loadCert(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.cert.Store(cert)
+	r.modTime = modTime
+	return nil
+}
+
+// GetCertificate 给tls.Config.GetCertificate使用，返回当前的证书
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.cert.Load(), nil
+}
+
+// Watch 每隔interval检查一次证书文件的修改时间，变化了就Reload，直到ctx结束
+// 加载失败只打日志，继续使用旧证书
+func (r *CertReloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !r.changed() {
+				continue
+			}
+			if err := r.Reload(); err != nil {
+				logger.Error("reload tls certificate", slog.String("cert", r.certFile), slog.Any("error", err))
+				continue
+			}
+			logger.Info("reloaded tls certificate", slog.String("cert", r.certFile))
+		}
+	}
+}
+
+// changed 证书或私钥文件的修改时间和上次加载时不一样
+// 加载失败时modTime不更新，所以坏文件在修好之前每次都会重试
+func (r *CertReloader) changed() bool {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return !modTime.Equal(r.modTime)
+}
+
+// latestModTime 证书和私钥两个文件里较新的修改时间
+func (r *CertReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, name := range []string{r.certFile, r.keyFile} {
+		fi, err := os.Stat(name)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if fi.ModTime().After(latest) {
+			latest = fi.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// loadCert 读取证书，私钥和证书不匹配或者证书已经过期都视为无效
+func loadCert(certFile, keyFile string) (*tls.Certificate, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load key pair: %w", err)
+	}
+	if cert.Leaf == nil {
+		return nil, errors.New("load key pair: no leaf certificate")
+	}
+	if now := time.Now(); now.After(cert.Leaf.NotAfter) {
+		return nil, fmt.Errorf("certificate expired at %s", cert.Leaf.NotAfter.Format(time.RFC3339))
+	}
+	return &cert, nil
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+// handshakeCN 和GetCertificate配置的TLS服务握手，返回服务端证书的CN
+func handshakeCN(t *testing.T, addr string) string {
+	t.Helper()
+	c, err := tls.Dial("tcp", addr, &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	return c.ConnectionState().PeerCertificates[0].Subject.CommonName
+}
+
+// serveTLS 用r提供证书的TLS服务，只做握手
+func serveTLS(t *testing.T, r *CertReloader) string {
+	t.Helper()
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{GetCertificate: r.GetCertificate})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(c net.Conn) {
+				defer c.Close()
+				c.(*tls.Conn).Handshake()
+			}(c)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCert(t, dir, "one")
+	r, err := NewCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := serveTLS(t, r)
+	if cn := handshakeCN(t, addr); cn != "one" {
+		t.Fatalf("CN = %q, want one", cn)
+	}
+
+	// 新证书无效时拒绝，继续用旧的
+	if err := os.WriteFile(certFile, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Reload(); err == nil {
+		t.Fatal("Reload of an invalid cert returned nil")
+	}
+	if cn := handshakeCN(t, addr); cn != "one" {
+		t.Fatalf("CN after invalid cert = %q, want one", cn)
+	}
+
+	writeCert(t, dir, "two")
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if cn := handshakeCN(t, addr); cn != "two" {
+		t.Fatalf("CN after reload = %q, want two", cn)
+	}
+}
+
+func TestCertReloaderWatch(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCert(t, dir, "one")
+	r, err := NewCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Watch(ctx, 5*time.Millisecond)
+	addr := serveTLS(t, r)
+
+	// 文件换了之后不用手动Reload，后面的握手拿到新证书
+	writeCert(t, dir, "two")
+	deadline := time.Now().Add(2 * time.Second)
+	for handshakeCN(t, addr) != "two" {
+		if time.Now().After(deadline) {
+			t.Fatal("Watch did not pick up the new certificate")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestNewCertReloaderMissingFile(t *testing.T) {
+	if _, err := NewCertReloader("missing.pem", "missing-key.pem"); err == nil {
+		t.Fatal("NewCertReloader with missing files returned nil")
+	}
+}
@@ -0,0 +1,33 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	gsync "gostudy/sync"
+)
+
+// 排队等待执行的最长时间，超过返回503
+const concurrencyQueueTimeout = time.Second
+
+// ConcurrencyLimitMiddleware 最多同时有max个请求在执行handler，其余的排队等待
+// 排队超过concurrencyQueueTimeout返回503，适合包在比较耗资源的路由上
+func ConcurrencyLimitMiddleware(max int) func(http.Handler) http.Handler {
+	sem := gsync.NewSemaphore(max)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			// 客户端先断开时也不用继续排队
+			ctx, cancel := context.WithTimeout(req.Context(), concurrencyQueueTimeout)
+			err := sem.Acquire(ctx)
+			cancel()
+			if err != nil {
+				w.Header().Set("Retry-After", "1")
+				writeJSONError(w, http.StatusServiceUnavailable, "server busy")
+				return
+			}
+			defer sem.Release()
+			next.ServeHTTP(w, req)
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestConcurrencyLimitMiddleware(t *testing.T) {
+	entered := make(chan struct{}, 1)
+	release := make(chan struct{})
+	h := ConcurrencyLimitMiddleware(1)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		entered <- struct{}{}
+		<-release
+		io.WriteString(w, "done")
+	}))
+	serve := func() <-chan *httptest.ResponseRecorder {
+		done := make(chan *httptest.ResponseRecorder, 1)
+		go func() {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			done <- rec
+		}()
+		return done
+	}
+
+	// 第一个请求占满名额
+	first := serve()
+	<-entered
+
+	// 第二个请求排队，等满concurrencyQueueTimeout后503
+	start := time.Now()
+	rec := <-serve()
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", rec.Code)
+	}
+	if waited := time.Since(start); waited < concurrencyQueueTimeout {
+		t.Fatalf("rejected after %v, want to queue for %v", waited, concurrencyQueueTimeout)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Fatal("no Retry-After header")
+	}
+
+	// 排队中的请求在第一个请求结束后拿到名额
+	third := serve()
+	select {
+	case <-entered:
+		t.Fatal("third request ran while the slot was taken")
+	case <-time.After(20 * time.Millisecond):
+	}
+	release <- struct{}{}
+	if rec := <-first; rec.Code != http.StatusOK {
+		t.Fatalf("first status = %d, want 200", rec.Code)
+	}
+	select {
+	case <-entered:
+	case <-time.After(time.Second):
+		t.Fatal("freed slot not handed to the queued request")
+	}
+	release <- struct{}{}
+	if rec := <-third; rec.Code != http.StatusOK {
+		t.Fatalf("third status = %d, want 200", rec.Code)
+	}
+}
+
+func TestConcurrencyLimitMiddlewareClientGone(t *testing.T) {
+	entered := make(chan struct{}, 1)
+	release := make(chan struct{})
+	defer close(release)
+	h := ConcurrencyLimitMiddleware(1)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		entered <- struct{}{}
+		<-release
+	}))
+	go h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	<-entered
+
+	// 客户端断开时不用等满排队时间
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	ctx, cancel := context.WithCancel(req.Context())
+	cancel()
+	rec := httptest.NewRecorder()
+	start := time.Now()
+	h.ServeHTTP(rec, req.WithContext(ctx))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", rec.Code)
+	}
+	if waited := time.Since(start); waited >= concurrencyQueueTimeout {
+		t.Fatalf("cancelled request queued for %v", waited)
+	}
+}
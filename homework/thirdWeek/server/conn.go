@@ -0,0 +1,18 @@
+package server
+
+import (
+	"net"
+	"net/http"
+)
+
+// SetConnStateHook 连接状态变化时调用fn，可以用来统计活跃和空闲的连接数
+// 需要在Run之前调用
+func (s *Server) SetConnStateHook(fn func(net.Conn, http.ConnState)) {
+	s.srv.ConnState = fn
+}
+
+// DisableKeepAlives 关闭keep-alive，当前请求处理完后连接就会断开，客户端会重新建连
+// Run在开始关闭时会调用，让客户端尽快连到别的实例上
+func (s *Server) DisableKeepAlives() {
+	s.srv.SetKeepAlivesEnabled(false)
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConnStateHook(t *testing.T) {
+	var mu sync.Mutex
+	var states []http.ConnState
+	s := NewServer(freeAddr(t))
+	s.SetConnStateHook(func(c net.Conn, st http.ConnState) {
+		mu.Lock()
+		defer mu.Unlock()
+		states = append(states, st)
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	base, errc := startServer(t, ctx, s)
+
+	// startServer探测/healthz时已经有连接了，只看这个新客户端的连接
+	mu.Lock()
+	states = nil
+	mu.Unlock()
+	client := &http.Client{Transport: &http.Transport{}}
+	defer client.CloseIdleConnections()
+	resp, err := client.Get(base + "/hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	want := []http.ConnState{http.StateNew, http.StateActive, http.StateIdle}
+	deadline := time.Now().Add(time.Second)
+	for {
+		mu.Lock()
+		got := slices.Clone(states)
+		mu.Unlock()
+		if slices.Equal(got, want) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("states = %v, want %v", got, want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	if err := waitRun(t, errc); err != nil {
+		t.Fatalf("Run = %v, want nil", err)
+	}
+}
+
+func TestDisableKeepAlives(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := NewServer(freeAddr(t))
+	base, errc := startServer(t, ctx, s)
+
+	s.DisableKeepAlives()
+	resp, err := http.Get(base + "/hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	// 关掉keep-alive后服务端在响应里要求断开连接
+	if !resp.Close {
+		t.Fatal("response does not close the connection after DisableKeepAlives")
+	}
+
+	cancel()
+	if err := waitRun(t, errc); err != nil {
+		t.Fatalf("Run = %v, want nil", err)
+	}
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+)
+
+// 预检请求允许的方法
+var corsAllowMethods = strings.Join([]string{
+	http.MethodGet,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodDelete,
+	http.MethodOptions,
+}, ", ")
+
+// CORSMiddleware 跨域处理，Origin在allowedOrigins里时设置Access-Control-Allow-Origin
+// allowedOrigins里有"*"时允许任意来源，但带凭证（Cookie/Authorization）的请求按规范不放行
+// OPTIONS预检请求直接返回204，不会进到后面的handler
+func CORSMiddleware(allowedOrigins []string) func(http.Handler) http.Handler {
+	allowAll := false
+	allowed := make(map[string]bool, len(allowedOrigins))
+	for _, o := range allowedOrigins {
+		if o == "*" {
+			allowAll = true
+			continue
+		}
+		allowed[o] = true
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			origin := req.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, req)
+				return
+			}
+			// 响应内容和Origin有关，缓存需要区分
+			w.Header().Add("Vary", "Origin")
+
+			allowOrigin := ""
+			credentials := false
+			switch {
+			case allowed[origin]:
+				allowOrigin = origin
+				credentials = true
+			case allowAll && !hasCredentials(req):
+				allowOrigin = "*"
+			}
+			// 不在允许列表里就不加任何CORS头，交给浏览器拦截
+			if allowOrigin == "" {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			h := w.Header()
+			h.Set("Access-Control-Allow-Origin", allowOrigin)
+			if credentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+			if isPreflight(req) {
+				h.Set("Access-Control-Allow-Methods", corsAllowMethods)
+				if reqHeaders := req.Header.Get("Access-Control-Request-Headers"); reqHeaders != "" {
+					h.Set("Access-Control-Allow-Headers", reqHeaders)
+				}
+				h.Set("Access-Control-Max-Age", "600")
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// isPreflight 浏览器发的预检请求是带Access-Control-Request-Method的OPTIONS
+func isPreflight(req *http.Request) bool {
+	return req.Method == http.MethodOptions && req.Header.Get("Access-Control-Request-Method") != ""
+}
+
+// hasCredentials 请求是否带了凭证
+func hasCredentials(req *http.Request) bool {
+	return req.Header.Get("Cookie") != "" || req.Header.Get("Authorization") != ""
+}
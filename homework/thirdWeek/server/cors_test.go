@@ -0,0 +1,88 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	tests := []struct {
+		name        string
+		allowed     []string
+		method      string
+		headers     map[string]string
+		wantOrigin  string
+		wantStatus  int
+		wantMethods bool
+	}{
+		{
+			name:       "matching origin",
+			allowed:    []string{"https://app.example"},
+			method:     http.MethodGet,
+			headers:    map[string]string{"Origin": "https://app.example"},
+			wantOrigin: "https://app.example",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "non-matching origin",
+			allowed:    []string{"https://app.example"},
+			method:     http.MethodGet,
+			headers:    map[string]string{"Origin": "https://evil.example"},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "wildcard",
+			allowed:    []string{"*"},
+			method:     http.MethodGet,
+			headers:    map[string]string{"Origin": "https://any.example"},
+			wantOrigin: "*",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "wildcard with credentials",
+			allowed:    []string{"*"},
+			method:     http.MethodGet,
+			headers:    map[string]string{"Origin": "https://any.example", "Cookie": "session=1"},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:    "preflight",
+			allowed: []string{"https://app.example"},
+			method:  http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                         "https://app.example",
+				"Access-Control-Request-Method":  "POST",
+				"Access-Control-Request-Headers": "Content-Type",
+			},
+			wantOrigin:  "https://app.example",
+			wantStatus:  http.StatusNoContent,
+			wantMethods: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := CORSMiddleware(tt.allowed)(http.HandlerFunc(helloServer))
+			req := httptest.NewRequest(tt.method, "/hello", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Fatalf("Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			methods := rec.Header().Get("Access-Control-Allow-Methods")
+			if (methods != "") != tt.wantMethods {
+				t.Fatalf("Allow-Methods = %q", methods)
+			}
+			if tt.wantMethods && rec.Header().Get("Access-Control-Allow-Headers") != "Content-Type" {
+				t.Fatalf("Allow-Headers = %q", rec.Header().Get("Access-Control-Allow-Headers"))
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// 等待请求处理完时检查计数的间隔
+const drainPollInterval = 10 * time.Millisecond
+
+// trackInflight 请求进来时计数加一，处理完减一
+func (s *Server) trackInflight(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		s.inflight.Add(1)
+		defer s.inflight.Add(-1)
+		next.ServeHTTP(w, req)
+	})
+}
+
+// Inflight 当前正在处理的请求数
+func (s *Server) Inflight() int64 {
+	return s.inflight.Load()
+}
+
+// WaitForDrain 等到正在处理的请求数降到0，ctx先结束则返回ctx.Err()
+func (s *Server) WaitForDrain(ctx context.Context) error {
+	if s.inflight.Load() == 0 {
+		return nil
+	}
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if s.inflight.Load() == 0 {
+				return nil
+			}
+		}
+	}
+}
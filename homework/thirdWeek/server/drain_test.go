@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// startSlow 通过trackInflight发一个阻塞到release关闭的请求，等它开始处理后返回
+func startSlow(t *testing.T, s *Server, release <-chan struct{}) <-chan struct{} {
+	t.Helper()
+	h := s.trackInflight(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		<-release
+	}))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+	}()
+	deadline := time.Now().Add(time.Second)
+	for s.Inflight() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Inflight = %d, want 1", s.Inflight())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return done
+}
+
+func TestWaitForDrain(t *testing.T) {
+	s := NewServer(freeAddr(t))
+	release := make(chan struct{})
+	done := startSlow(t, s, release)
+
+	drained := make(chan error, 1)
+	go func() { drained <- s.WaitForDrain(context.Background()) }()
+	select {
+	case err := <-drained:
+		t.Fatalf("WaitForDrain returned %v with a request in flight", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case err := <-drained:
+		if err != nil {
+			t.Fatalf("WaitForDrain = %v, want nil", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("WaitForDrain did not return after the request finished")
+	}
+	// 返回时请求一定已经处理完了
+	select {
+	case <-done:
+	default:
+		t.Fatal("WaitForDrain returned before the handler finished")
+	}
+	if n := s.Inflight(); n != 0 {
+		t.Fatalf("Inflight = %d after drain, want 0", n)
+	}
+}
+
+func TestWaitForDrainTimeout(t *testing.T) {
+	s := NewServer(freeAddr(t))
+	release := make(chan struct{})
+	defer close(release)
+	startSlow(t, s, release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if err := s.WaitForDrain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("WaitForDrain = %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestWaitForDrainIdle(t *testing.T) {
+	s := NewServer(freeAddr(t))
+	// 没有请求时即使ctx已经取消也直接返回nil
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := s.WaitForDrain(ctx); err != nil {
+		t.Fatalf("WaitForDrain = %v, want nil", err)
+	}
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// echo请求体最大1MB
+const maxEchoBodyBytes = 1 << 20
+
+type echoRequest struct {
+	Message string `json:"message"`
+}
+
+type echoResponse struct {
+	Echo string `json:"echo"`
+}
+
+// echoServer POST {"message": "..."}，返回{"echo": "..."}
+func echoServer(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	req.Body = http.MaxBytesReader(w, req.Body, maxEchoBodyBytes)
+	dec := json.NewDecoder(req.Body)
+	dec.DisallowUnknownFields()
+
+	var in echoRequest
+	if err := dec.Decode(&in); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			writeJSONError(w, http.StatusRequestEntityTooLarge, "request body too large")
+			return
+		}
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	// body里只能有一个JSON对象
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		writeJSONError(w, http.StatusBadRequest, "body must contain a single JSON object")
+		return
+	}
+	writeJSON(w, http.StatusOK, echoResponse{Echo: in.Message})
+}
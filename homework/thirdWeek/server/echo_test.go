@@ -0,0 +1,59 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEchoServer(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		body      string
+		status    int
+		wantEcho  string
+		wantError bool
+	}{
+		{name: "valid", method: http.MethodPost, body: `{"message":"hi"}`, status: http.StatusOK, wantEcho: "hi"},
+		{name: "invalid JSON", method: http.MethodPost, body: `{"message":`, status: http.StatusBadRequest, wantError: true},
+		{name: "unknown field", method: http.MethodPost, body: `{"msg":"hi"}`, status: http.StatusBadRequest, wantError: true},
+		{name: "trailing data", method: http.MethodPost, body: `{"message":"a"}{}`, status: http.StatusBadRequest, wantError: true},
+		{
+			name:      "oversized",
+			method:    http.MethodPost,
+			body:      `{"message":"` + strings.Repeat("x", maxEchoBodyBytes) + `"}`,
+			status:    http.StatusRequestEntityTooLarge,
+			wantError: true,
+		},
+		{name: "wrong method", method: http.MethodGet, status: http.StatusMethodNotAllowed, wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			echoServer(rec, httptest.NewRequest(tt.method, "/echo", strings.NewReader(tt.body)))
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+				t.Fatalf("Content-Type = %q", ct)
+			}
+			var resp map[string]string
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantError {
+				if resp["error"] == "" {
+					t.Fatalf("response %v has no error envelope", resp)
+				}
+				return
+			}
+			if resp["echo"] != tt.wantEcho {
+				t.Fatalf("echo = %q, want %q", resp["echo"], tt.wantEcho)
+			}
+		})
+	}
+}
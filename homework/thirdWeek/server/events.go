@@ -0,0 +1,65 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// 每个/events连接最多缓存的消息数，客户端读得慢时多出来的消息会被丢掉
+const eventsBuffer = 16
+
+// Publish 把msg推送给所有连着/events的客户端
+func (s *Server) Publish(msg string) {
+	s.events.Publish(msg)
+}
+
+// eventsServer Server-Sent Events，订阅broker并把每条消息写成data帧推给客户端
+// 客户端断开（req.Context()结束）或服务关闭（broker关闭）时退出并取消订阅
+func (s *Server) eventsServer(w http.ResponseWriter, req *http.Request) {
+	rc := http.NewResponseController(w)
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	// 让nginx之类的反向代理不要缓冲响应
+	h.Set("X-Accel-Buffering", "no")
+	if err := rc.Flush(); errors.Is(err, http.ErrNotSupported) {
+		writeJSONError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+	// 事件流是长连接，不受Server的WriteTimeout限制
+	rc.SetWriteDeadline(time.Time{})
+
+	msgs, cancel := s.events.Subscribe()
+	defer cancel()
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case msg, ok := <-msgs:
+			if !ok {
+				return
+			}
+			if err := writeEvent(w, msg); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeEvent 写一帧SSE，多行消息每行都要加data:前缀
+func writeEvent(w http.ResponseWriter, msg string) error {
+	var b strings.Builder
+	for _, line := range strings.Split(msg, "\n") {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	b.WriteString("\n")
+	_, err := w.Write([]byte(b.String()))
+	return err
+}
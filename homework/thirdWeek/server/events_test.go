@@ -0,0 +1,117 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readEvents 把SSE响应解析成一条条消息，多行data拼回原来的消息
+func readEvents(resp *http.Response) <-chan string {
+	// 留点缓冲，测试提前结束时多出来的ping不会让goroutine卡在发送上
+	out := make(chan string, 64)
+	go func() {
+		defer close(out)
+		sc := bufio.NewScanner(resp.Body)
+		var lines []string
+		for sc.Scan() {
+			line := sc.Text()
+			if line == "" {
+				out <- strings.Join(lines, "\n")
+				lines = nil
+				continue
+			}
+			lines = append(lines, strings.TrimPrefix(line, "data: "))
+		}
+	}()
+	return out
+}
+
+func TestEventsStream(t *testing.T) {
+	s := NewServer("")
+	ts := httptest.NewServer(http.HandlerFunc(s.eventsServer))
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	if cc := resp.Header.Get("Cache-Control"); cc != "no-cache" {
+		t.Fatalf("Cache-Control = %q", cc)
+	}
+	events := readEvents(resp)
+
+	// 响应头先于订阅发出，发ping直到客户端收到，确认已经订阅上
+	for subscribed := false; !subscribed; {
+		s.Publish("ping")
+		select {
+		case <-events:
+			subscribed = true
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	want := []string{"first", "second", "multi\nline"}
+	for _, msg := range want {
+		s.Publish(msg)
+	}
+	for _, w := range want {
+		var got string
+		// 跳过订阅前多发的ping
+		for got = "ping"; got == "ping"; {
+			select {
+			case got = <-events:
+			case <-time.After(time.Second):
+				t.Fatalf("no event, want %q", w)
+			}
+		}
+		if got != w {
+			t.Fatalf("event = %q, want %q", got, w)
+		}
+	}
+}
+
+func TestEventsFrames(t *testing.T) {
+	rec := httptest.NewRecorder()
+	if err := writeEvent(rec, "a\nb"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := rec.Body.String(), "data: a\ndata: b\n\n"; got != want {
+		t.Fatalf("frame = %q, want %q", got, want)
+	}
+}
+
+func TestEventsClientDisconnect(t *testing.T) {
+	s := NewServer("")
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(ctx)
+	done := make(chan struct{})
+	go func() {
+		s.eventsServer(httptest.NewRecorder(), req)
+		close(done)
+	}()
+	// 客户端断开后handler要退出，不能一直挂着
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("handler still running after the client went away")
+	}
+	// 退出时取消了订阅，关闭broker不需要等它
+	closeCtx, closeCancel := context.WithTimeout(context.Background(), time.Second)
+	defer closeCancel()
+	if err := s.events.Close(closeCtx); err != nil {
+		t.Fatalf("close broker: %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGzipMiddleware(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		contentType    string
+		wantGzip       bool
+	}{
+		{name: "gzip", acceptEncoding: "gzip, deflate", wantGzip: true},
+		{name: "no header"},
+		{name: "q=0", acceptEncoding: "gzip;q=0"},
+		{name: "already compressed", acceptEncoding: "gzip", contentType: "image/png"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := GzipMiddleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				w.Header().Set("Content-Length", "8")
+				// 直接调用WriteHeader，gzip尾部仍然要写出去
+				w.WriteHeader(http.StatusCreated)
+				io.WriteString(w, "hello Go")
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/hello", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusCreated {
+				t.Fatalf("status = %d, want 201", rec.Code)
+			}
+			var body io.Reader = rec.Body
+			if tt.wantGzip {
+				if rec.Header().Get("Content-Encoding") != "gzip" || rec.Header().Get("Content-Length") != "" {
+					t.Fatalf("headers = %v", rec.Header())
+				}
+				zr, err := gzip.NewReader(rec.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				body = zr
+			} else if enc := rec.Header().Get("Content-Encoding"); enc != "" {
+				t.Fatalf("Content-Encoding = %q, want none", enc)
+			}
+			b, err := io.ReadAll(body)
+			if err != nil || string(b) != "hello Go" {
+				t.Fatalf("body = %q, %v", b, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 每个依赖检查默认的超时时间
+const defaultCheckTimeout = 2 * time.Second
+
+// HealthChecker 汇总各个依赖（数据库、缓存等）的健康检查
+type HealthChecker struct {
+	timeout time.Duration
+
+	mu     sync.Mutex
+	checks map[string]func(ctx context.Context) error
+}
+
+// checkResult 单个依赖的检查结果
+type checkResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// healthReport /readyz返回的JSON
+type healthReport struct {
+	Status string                 `json:"status"`
+	Checks map[string]checkResult `json:"checks"`
+}
+
+// NewHealthChecker 创建HealthChecker，每个检查最多执行timeout，timeout<=0时用默认的2s
+func NewHealthChecker(timeout time.Duration) *HealthChecker {
+	if timeout <= 0 {
+		timeout = defaultCheckTimeout
+	}
+	return &HealthChecker{
+		timeout: timeout,
+		checks:  make(map[string]func(ctx context.Context) error),
+	}
+}
+
+// Register 以name注册一个依赖检查，返回nil表示健康，同名的会被覆盖
+func (h *HealthChecker) Register(name string, check func(ctx context.Context) error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks[name] = check
+}
+
+// Check 并发执行所有检查，返回每个依赖的结果和是否全部通过
+func (h *HealthChecker) Check(ctx context.Context) (map[string]checkResult, bool) {
+	h.mu.Lock()
+	names := make([]string, 0, len(h.checks))
+	for name := range h.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	checks := make([]func(context.Context) error, len(names))
+	for i, name := range names {
+		checks[i] = h.checks[name]
+	}
+	h.mu.Unlock()
+
+	errs := make([]error, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, h.timeout)
+			defer cancel()
+			errs[i] = check(ctx)
+		}()
+	}
+	wg.Wait()
+
+	results := make(map[string]checkResult, len(names))
+	healthy := true
+	for i, name := range names {
+		if errs[i] != nil {
+			healthy = false
+			results[name] = checkResult{Status: "fail", Error: errs[i].Error()}
+			continue
+		}
+		results[name] = checkResult{Status: "ok"}
+	}
+	return results, healthy
+}
+
+// ServeHTTP 全部检查通过返回200，否则返回503，body里列出每个依赖的状态
+func (h *HealthChecker) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	results, healthy := h.Check(req.Context())
+	status, code := "ok", http.StatusOK
+	if !healthy {
+		status, code = "fail", http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, healthReport{Status: status, Checks: results})
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHealthChecker(t *testing.T) {
+	ok := func(ctx context.Context) error { return nil }
+	down := func(ctx context.Context) error { return errors.New("connection refused") }
+	// 超过单个检查的超时时间，应该按失败处理而不是卡住整个请求
+	hang := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	tests := []struct {
+		name       string
+		checks     map[string]func(context.Context) error
+		wantCode   int
+		wantStatus string
+		wantChecks map[string]checkResult
+	}{
+		{
+			name:       "all pass",
+			checks:     map[string]func(context.Context) error{"db": ok, "cache": ok},
+			wantCode:   http.StatusOK,
+			wantStatus: "ok",
+			wantChecks: map[string]checkResult{"db": {Status: "ok"}, "cache": {Status: "ok"}},
+		},
+		{
+			name:       "one fails",
+			checks:     map[string]func(context.Context) error{"db": ok, "cache": down},
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: "fail",
+			wantChecks: map[string]checkResult{
+				"db":    {Status: "ok"},
+				"cache": {Status: "fail", Error: "connection refused"},
+			},
+		},
+		{
+			name:       "timeout",
+			checks:     map[string]func(context.Context) error{"db": hang},
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: "fail",
+			wantChecks: map[string]checkResult{"db": {Status: "fail", Error: context.DeadlineExceeded.Error()}},
+		},
+		{
+			name:       "no checks",
+			wantCode:   http.StatusOK,
+			wantStatus: "ok",
+			wantChecks: map[string]checkResult{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHealthChecker(20 * time.Millisecond)
+			for name, check := range tt.checks {
+				h.Register(name, check)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			var report healthReport
+			if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+				t.Fatalf("decode %q: %v", rec.Body, err)
+			}
+			if report.Status != tt.wantStatus {
+				t.Fatalf("status = %q, want %q", report.Status, tt.wantStatus)
+			}
+			if len(report.Checks) != len(tt.wantChecks) {
+				t.Fatalf("checks = %v, want %v", report.Checks, tt.wantChecks)
+			}
+			for name, want := range tt.wantChecks {
+				if got := report.Checks[name]; got != want {
+					t.Fatalf("check %s = %+v, want %+v", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestServerReadyz(t *testing.T) {
+	s := NewServer("")
+	s.RegisterHealthCheck("db", func(ctx context.Context) error { return errors.New("down") })
+
+	rec := httptest.NewRecorder()
+	s.readyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", rec.Code)
+	}
+
+	// 开始关闭后不再探测依赖
+	s.RegisterHealthCheck("db", func(ctx context.Context) error { return nil })
+	s.shuttingDown.Store(true)
+	rec = httptest.NewRecorder()
+	s.readyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status while shutting down = %d, want 503", rec.Code)
+	}
+}
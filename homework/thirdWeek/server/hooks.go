@@ -0,0 +1,31 @@
+package server
+
+import (
+	"context"
+	"errors"
+)
+
+// OnShutdown 注册关闭时执行的回调，比如关闭数据库连接池、刷缓存
+// 回调在服务停止接收请求之后、Run返回之前执行，按注册的相反顺序（后注册先执行），
+// ctx就是优雅关闭的grace时间；某个回调出错不影响其他回调，所有错误会合并到Run的返回值里
+func (s *Server) OnShutdown(fn func(ctx context.Context) error) {
+	s.hooksMu.Lock()
+	defer s.hooksMu.Unlock()
+	s.hooks = append(s.hooks, fn)
+}
+
+// runShutdownHooks 按后进先出的顺序执行所有回调
+func (s *Server) runShutdownHooks(ctx context.Context) error {
+	s.hooksMu.Lock()
+	hooks := make([]func(context.Context) error, len(s.hooks))
+	copy(hooks, s.hooks)
+	s.hooksMu.Unlock()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if err := hooks[i](ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
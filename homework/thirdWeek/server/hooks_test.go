@@ -0,0 +1,44 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestOnShutdown(t *testing.T) {
+	s := NewServer(freeAddr(t))
+	var mu sync.Mutex
+	var order []string
+	errHook := errors.New("flush failed")
+	hook := func(name string, err error) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			// 回调拿到的是带grace超时的ctx，服务这时已经不再接收请求
+			if _, ok := ctx.Deadline(); !ok {
+				t.Errorf("hook %s: ctx has no deadline", name)
+			}
+			if p := s.Phase(); p != PhaseHooks {
+				t.Errorf("hook %s ran in phase %v", name, p)
+			}
+			order = append(order, name)
+			return err
+		}
+	}
+	s.OnShutdown(hook("first", nil))
+	s.OnShutdown(hook("second", errHook))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, errc := startServer(t, ctx, s)
+	cancel()
+	// 一个回调出错不影响另一个执行，错误合并进Run的返回值
+	if err := waitRun(t, errc); !errors.Is(err, errHook) {
+		t.Fatalf("Run = %v, want the hook error", err)
+	}
+	if want := []string{"second", "first"}; !slices.Equal(order, want) {
+		t.Fatalf("hooks ran in order %v, want %v", order, want)
+	}
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+)
+
+// ErrResponseTooLarge 响应超过ResponseLimiter的上限后，handler再写返回这个错误
+var ErrResponseTooLarge = errors.New("server: response size limit exceeded")
+
+// MaxBodyMiddleware 限制请求体最多limit字节
+// Content-Length已经超过时直接返回413；否则用MaxBytesReader包一层，handler读超了会拿到*http.MaxBytesError
+func MaxBodyMiddleware(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.ContentLength > limit {
+				writeJSONError(w, http.StatusRequestEntityTooLarge, "request body too large")
+				return
+			}
+			req.Body = http.MaxBytesReader(w, req.Body, limit)
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// ResponseLimiter 限制响应体最多limit字节，超出的部分被截掉并打一条日志
+func ResponseLimiter(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			lw := &limitedWriter{ResponseWriter: w, req: req, remaining: limit}
+			next.ServeHTTP(lw, req)
+		})
+	}
+}
+
+// limitedWriter 写满remaining之后截断，只记一次日志
+type limitedWriter struct {
+	http.ResponseWriter
+	req       *http.Request
+	remaining int64
+	logged    bool
+}
+
+func (w *limitedWriter) Write(b []byte) (int, error) {
+	if int64(len(b)) <= w.remaining {
+		n, err := w.ResponseWriter.Write(b)
+		w.remaining -= int64(n)
+		return n, err
+	}
+	n, err := w.ResponseWriter.Write(b[:w.remaining])
+	w.remaining -= int64(n)
+	if err != nil {
+		return n, err
+	}
+	if !w.logged {
+		w.logged = true
+		id, _ := RequestIDFromContext(w.req.Context())
+		logger.Warn("response size limit exceeded",
+			slog.String("request_id", id),
+			slog.String("path", w.req.URL.Path),
+			slog.Int("dropped", len(b)-n),
+		)
+	}
+	return n, ErrResponseTooLarge
+}
+
+// Unwrap 让http.ResponseController能拿到底层的ResponseWriter
+func (w *limitedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
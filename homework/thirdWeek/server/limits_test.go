@@ -0,0 +1,77 @@
+package server
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// readBody 读完请求体，超过MaxBytesReader的限制时返回413
+func readBody(w http.ResponseWriter, req *http.Request) {
+	b, err := io.ReadAll(req.Body)
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		writeJSONError(w, http.StatusRequestEntityTooLarge, "request body too large")
+		return
+	}
+	w.Write(b)
+}
+
+func TestMaxBodyMiddleware(t *testing.T) {
+	h := MaxBodyMiddleware(8)(http.HandlerFunc(readBody))
+	tests := []struct {
+		name string
+		body string
+		// chunked 不带Content-Length，只能在读的时候发现超限
+		chunked bool
+		code    int
+	}{
+		{name: "within limit", body: "12345678", code: http.StatusOK},
+		{name: "content length over limit", body: "123456789", code: http.StatusRequestEntityTooLarge},
+		{name: "chunked over limit", body: "123456789", chunked: true, code: http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tt.code {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.code)
+			}
+			if tt.code == http.StatusOK && rec.Body.String() != tt.body {
+				t.Fatalf("body = %q, want %q", rec.Body.String(), tt.body)
+			}
+		})
+	}
+}
+
+func TestResponseLimiter(t *testing.T) {
+	logs := captureLogs(t)
+	var writeErrs []error
+	h := ResponseLimiter(4)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		for _, s := range []string{"ab", "cdef", "gh"} {
+			_, err := io.WriteString(w, s)
+			writeErrs = append(writeErrs, err)
+		}
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/big", nil))
+
+	if body := rec.Body.String(); body != "abcd" {
+		t.Fatalf("body = %q, want it truncated to abcd", body)
+	}
+	if writeErrs[0] != nil || !errors.Is(writeErrs[1], ErrResponseTooLarge) || !errors.Is(writeErrs[2], ErrResponseTooLarge) {
+		t.Fatalf("write errors = %v", writeErrs)
+	}
+	// 超限只记一次日志
+	entries := logs.entries(t)
+	if len(entries) != 1 || entries[0]["msg"] != "response size limit exceeded" || entries[0]["path"] != "/big" {
+		t.Fatalf("logs = %v", entries)
+	}
+}
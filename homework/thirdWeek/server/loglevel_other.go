@@ -0,0 +1,11 @@
+//go:build !unix
+
+package server
+
+import "os"
+
+// 其他平台没有SIGUSR1/SIGUSR2，不支持用信号切换日志级别
+var (
+	levelUpSignal   os.Signal
+	levelDownSignal os.Signal
+)
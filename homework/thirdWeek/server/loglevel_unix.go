@@ -0,0 +1,14 @@
+//go:build unix
+
+package server
+
+import (
+	"os"
+	"syscall"
+)
+
+// SIGUSR1打开Debug日志，SIGUSR2恢复到Info
+var (
+	levelUpSignal   os.Signal = syscall.SIGUSR1
+	levelDownSignal os.Signal = syscall.SIGUSR2
+)
@@ -0,0 +1,182 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 延迟直方图的桶（秒），和prometheus客户端的默认值一样
+var defaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// unmatchedLabel 没有匹配到路由的请求和不认识的方法统一记成这个值
+const unmatchedLabel = "other"
+
+// Metrics 简单的指标注册表，不依赖prometheus客户端，按文本格式输出
+// http_requests_total 按method/path/status计数
+// http_request_duration_seconds 按method/path统计延迟直方图
+// path是匹配到的路由（比如"/static/"），不是请求的原始路径，否则每个不同的路径都会新建一组序列，内存无限增长
+type Metrics struct {
+	mu        sync.Mutex
+	requests  map[requestKey]uint64
+	durations map[routeKey]*histogram
+	// route 返回请求匹配到的路由，为nil时使用ServeMux设置的req.Pattern
+	route func(req *http.Request) string
+}
+
+type routeKey struct {
+	method string
+	path   string
+}
+
+type requestKey struct {
+	routeKey
+	status int
+}
+
+type histogram struct {
+	// counts[i]是落在第i个桶里的次数（不累加），输出时再累加
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewMetrics 创建空的注册表
+func NewMetrics() *Metrics {
+	return &Metrics{
+		requests:  make(map[requestKey]uint64),
+		durations: make(map[routeKey]*histogram),
+	}
+}
+
+// Observe 记录一次请求，path应该是路由而不是原始路径
+func (m *Metrics) Observe(method, path string, status int, d time.Duration) {
+	route := routeKey{method: method, path: path}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[requestKey{routeKey: route, status: status}]++
+	h, ok := m.durations[route]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(defaultBuckets))}
+		m.durations[route] = h
+	}
+	sec := d.Seconds()
+	for i, le := range defaultBuckets {
+		if sec <= le {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += sec
+	h.count++
+}
+
+// Middleware 统计经过的每个请求
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		rec := newStatusRecorder(w)
+		next.ServeHTTP(rec, req)
+		m.Observe(methodLabel(req.Method), m.routeLabel(req), rec.status, time.Since(start))
+	})
+}
+
+// routeLabel 请求匹配到的路由，没有匹配上的（404、扫描器乱试的路径）都归到unmatchedLabel
+// 中间件里用WithContext换过请求时，ServeMux只会把Pattern设置在新的请求上，
+// 所以Server用route自己到mux里查
+func (m *Metrics) routeLabel(req *http.Request) string {
+	pattern := req.Pattern
+	if m.route != nil {
+		pattern = m.route(req)
+	}
+	if pattern == "" {
+		return unmatchedLabel
+	}
+	return pattern
+}
+
+// methodLabel 方法名是客户端随便填的，只保留标准方法
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return unmatchedLabel
+}
+
+// ServeHTTP 按prometheus文本格式输出所有指标
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// WriteTo 按prometheus文本格式写出所有指标，输出按标签排序保证稳定
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	m.mu.Lock()
+
+	reqKeys := make([]requestKey, 0, len(m.requests))
+	for k := range m.requests {
+		reqKeys = append(reqKeys, k)
+	}
+	sort.Slice(reqKeys, func(i, j int) bool {
+		if reqKeys[i].routeKey != reqKeys[j].routeKey {
+			return reqKeys[i].routeKey.less(reqKeys[j].routeKey)
+		}
+		return reqKeys[i].status < reqKeys[j].status
+	})
+	b.WriteString("# HELP http_requests_total Total number of HTTP requests.\n")
+	b.WriteString("# TYPE http_requests_total counter\n")
+	for _, k := range reqKeys {
+		fmt.Fprintf(&b, "http_requests_total{%s,status=\"%d\"} %d\n", k.labels(), k.status, m.requests[k])
+	}
+
+	routes := make([]routeKey, 0, len(m.durations))
+	for k := range m.durations {
+		routes = append(routes, k)
+	}
+	sort.Slice(routes, func(i, j int) bool { return routes[i].less(routes[j]) })
+	b.WriteString("# HELP http_request_duration_seconds HTTP request latency in seconds.\n")
+	b.WriteString("# TYPE http_request_duration_seconds histogram\n")
+	for _, k := range routes {
+		h := m.durations[k]
+		labels := k.labels()
+		var cumulative uint64
+		for i, le := range defaultBuckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(&b, "http_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n",
+				labels, strconv.FormatFloat(le, 'g', -1, 64), cumulative)
+		}
+		fmt.Fprintf(&b, "http_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, h.count)
+		fmt.Fprintf(&b, "http_request_duration_seconds_sum{%s} %s\n", labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(&b, "http_request_duration_seconds_count{%s} %d\n", labels, h.count)
+	}
+
+	m.mu.Unlock()
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func (k routeKey) less(o routeKey) bool {
+	if k.path != o.path {
+		return k.path < o.path
+	}
+	return k.method < o.method
+}
+
+func (k routeKey) labels() string {
+	return fmt.Sprintf("method=\"%s\",path=\"%s\"", escapeLabel(k.method), escapeLabel(k.path))
+}
+
+// 标签值里的反斜杠、双引号和换行需要转义
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
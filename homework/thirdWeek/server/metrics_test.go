@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServerMetrics(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	base, errc := runServer(t, ctx)
+
+	get(t, base+"/hello")
+	get(t, base+"/hello")
+	// 不存在的路径不能各自成为一组序列
+	get(t, base+"/nope/1")
+	get(t, base+"/nope/2")
+
+	_, body := get(t, base+"/metrics")
+	for _, want := range []string{
+		`http_requests_total{method="GET",path="/hello",status="200"} 2`,
+		`http_requests_total{method="GET",path="other",status="404"} 2`,
+		`http_request_duration_seconds_count{method="GET",path="/hello"} 2`,
+		`http_request_duration_seconds_bucket{method="GET",path="/hello",le="+Inf"} 2`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("/metrics missing %s", want)
+		}
+	}
+	if strings.Contains(body, "/nope") {
+		t.Errorf("/metrics has a series per unmatched path:\n%s", body)
+	}
+
+	cancel()
+	if err := waitRun(t, errc); err != nil {
+		t.Fatalf("Run = %v, want nil", err)
+	}
+}
+
+func TestMetricsMiddlewareLabels(t *testing.T) {
+	m := NewMetrics()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/{id}", func(w http.ResponseWriter, req *http.Request) {})
+	h := m.Middleware(mux)
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/users/1", nil),
+		httptest.NewRequest(http.MethodGet, "/users/2", nil),
+		httptest.NewRequest("BREW", "/users/3", nil),
+	} {
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	var b strings.Builder
+	m.WriteTo(&b)
+	for _, want := range []string{
+		`http_requests_total{method="GET",path="/users/{id}",status="200"} 2`,
+		`http_requests_total{method="other",path="/users/{id}",status="200"} 1`,
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("metrics missing %s:\n%s", want, b.String())
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"time"
+)
+
+// logLevel 默认logger的日志级别，运行时可以通过信号在Info和Debug之间切换
+var logLevel = new(slog.LevelVar)
+
+// logger 中间件统一使用的结构化日志，输出JSON
+var logger = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel}))
+
+// SetLogger 替换中间件使用的logger，需要在服务启动前调用
+// 想继续用信号切换日志级别，l的handler需要使用LogLevel()返回的LevelVar
+func SetLogger(l *slog.Logger) {
+	logger = l
+}
+
+// LogLevel 返回默认logger使用的日志级别
+func LogLevel() *slog.LevelVar {
+	return logLevel
+}
+
+// Chain 把多个中间件按顺序组合起来，Chain(a, b, c)(h)等价于a(b(c(h)))，a在最外层
+// 没有中间件时原样返回h
+func Chain(middlewares ...func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			h = middlewares[i](h)
+		}
+		return h
+	}
+}
+
+// statusRecorder 包装ResponseWriter，记录状态码和写出的字节数
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	size   int
+}
+
+func newStatusRecorder(w http.ResponseWriter) *statusRecorder {
+	// handler没有调用WriteHeader时默认就是200
+	return &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(b)
+	r.size += n
+	return n, err
+}
+
+// Unwrap 让http.ResponseController能拿到底层的ResponseWriter
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// LoggingMiddleware 记录每个请求的方法、路径、状态码、响应大小和耗时
+func LoggingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		rec := newStatusRecorder(w)
+		next.ServeHTTP(rec, req)
+		id, _ := RequestIDFromContext(req.Context())
+		logger.Info("http request",
+			slog.String("request_id", id),
+			slog.String("method", req.Method),
+			slog.String("path", req.URL.Path),
+			slog.Int("status", rec.status),
+			slog.Int("size", rec.size),
+			slog.Duration("latency", time.Since(start)),
+		)
+	})
+}
+
+// RecoverMiddleware 捕获handler里的panic，记录堆栈并返回500，避免一个handler拖垮整个服务
+// http.ErrAbortHandler是标准库用来中断响应的，需要继续panic交给net/http处理
+func RecoverMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		defer func() {
+			err := recover()
+			if err == nil {
+				return
+			}
+			if err == http.ErrAbortHandler {
+				panic(err)
+			}
+			logger.Error("http handler panic",
+				slog.String("method", req.Method),
+				slog.String("path", req.URL.Path),
+				slog.Any("panic", err),
+				slog.String("stack", string(debug.Stack())),
+			)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}()
+		next.ServeHTTP(w, req)
+	})
+}
+
+// RequestIDHeader 请求ID使用的header
+const RequestIDHeader = "X-Request-ID"
+
+type ctxKey int
+
+const (
+	requestIDKey ctxKey = iota
+	paramsKey
+)
+
+// RequestIDMiddleware 给每个请求分配请求ID：优先使用请求里带的X-Request-ID，没有就生成一个
+// 请求ID放进context，同时在响应header里返回
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		id := req.Header.Get(RequestIDHeader)
+		if id == "" {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		ctx := context.WithValue(req.Context(), requestIDKey, id)
+		next.ServeHTTP(w, req.WithContext(ctx))
+	})
+}
+
+// RequestIDFromContext 取出RequestIDMiddleware放进context的请求ID
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey).(string)
+	return id, ok
+}
+
+// newRequestID 生成16字节随机数的hex字符串
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,243 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// logBuffer 可以被多个goroutine同时写的日志缓冲
+type logBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// entries 把JSON日志按行解析出来
+func (b *logBuffer) entries(t *testing.T) []map[string]any {
+	t.Helper()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var out []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(b.buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var e map[string]any
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("log line %q: %v", line, err)
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+// captureLogs 把中间件的logger换成写到缓冲里的JSON logger，测试结束后恢复
+func captureLogs(t *testing.T) *logBuffer {
+	t.Helper()
+	buf := new(logBuffer)
+	old := logger
+	SetLogger(slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	t.Cleanup(func() { SetLogger(old) })
+	return buf
+}
+
+// tagMiddleware 在响应的X-Order头后面追加name
+func tagMiddleware(name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Add("X-Order", name)
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+func TestChain(t *testing.T) {
+	final := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Add("X-Order", "handler")
+	})
+	tests := []struct {
+		name        string
+		middlewares []func(http.Handler) http.Handler
+		want        []string
+	}{
+		{name: "empty", want: []string{"handler"}},
+		{
+			name:        "a outermost",
+			middlewares: []func(http.Handler) http.Handler{tagMiddleware("a"), tagMiddleware("b"), tagMiddleware("c")},
+			want:        []string{"a", "b", "c", "handler"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := Chain(tt.middlewares...)(final)
+			// 同一个链多次调用顺序不变
+			for i := 0; i < 2; i++ {
+				rec := httptest.NewRecorder()
+				h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+				if got := rec.Header().Values("X-Order"); !slices.Equal(got, tt.want) {
+					t.Fatalf("X-Order = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		status  float64
+		size    float64
+	}{
+		{
+			name: "implicit 200",
+			handler: func(w http.ResponseWriter, req *http.Request) {
+				io.WriteString(w, "hello Go")
+			},
+			status: 200,
+			size:   8,
+		},
+		{
+			name: "explicit status",
+			handler: func(w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(http.StatusTeapot)
+			},
+			status: 418,
+			size:   0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureLogs(t)
+			rec := httptest.NewRecorder()
+			LoggingMiddleware(tt.handler).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/hello", nil))
+
+			entries := logs.entries(t)
+			if len(entries) != 1 {
+				t.Fatalf("got %d log entries, want 1", len(entries))
+			}
+			e := entries[0]
+			if e["msg"] != "http request" || e["method"] != "POST" || e["path"] != "/hello" {
+				t.Fatalf("unexpected entry %v", e)
+			}
+			if e["status"] != tt.status || e["size"] != tt.size {
+				t.Fatalf("status/size = %v/%v, want %v/%v", e["status"], e["size"], tt.status, tt.size)
+			}
+			if _, ok := e["latency"]; !ok {
+				t.Fatal("latency missing from log entry")
+			}
+		})
+	}
+}
+
+func TestRecoverMiddleware(t *testing.T) {
+	logs := captureLogs(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/panic", func(w http.ResponseWriter, req *http.Request) {
+		panic("boom")
+	})
+	mux.HandleFunc("/hello", helloServer)
+	ts := httptest.NewServer(RecoverMiddleware(mux))
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/panic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("GET /panic = %d, want 500", resp.StatusCode)
+	}
+	// 服务没有被panic拖垮，还能处理下一个请求
+	code, body := get(t, ts.URL+"/hello")
+	if code != http.StatusOK || body != "hello Go" {
+		t.Fatalf("GET /hello after panic = %d %q", code, body)
+	}
+
+	entries := logs.entries(t)
+	if len(entries) == 0 || entries[0]["panic"] != "boom" || entries[0]["stack"] == "" {
+		t.Fatalf("panic not logged with stack: %v", entries)
+	}
+}
+
+func TestRecoverMiddlewareRepanicsAbort(t *testing.T) {
+	h := RecoverMiddleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	defer func() {
+		if p := recover(); p != http.ErrAbortHandler {
+			t.Fatalf("recovered %v, want http.ErrAbortHandler", p)
+		}
+	}()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	tests := []struct {
+		name   string
+		preset string
+	}{
+		{name: "preset", preset: "abc-123"},
+		{name: "generated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fromCtx string
+			h := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				fromCtx, _ = RequestIDFromContext(req.Context())
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/hello", nil)
+			if tt.preset != "" {
+				req.Header.Set(RequestIDHeader, tt.preset)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			got := rec.Header().Get(RequestIDHeader)
+			if got != fromCtx {
+				t.Fatalf("response ID %q != context ID %q", got, fromCtx)
+			}
+			if tt.preset != "" && got != tt.preset {
+				t.Fatalf("ID = %q, want preset %q", got, tt.preset)
+			}
+			if tt.preset == "" {
+				if _, err := hex.DecodeString(got); err != nil || len(got) != 32 {
+					t.Fatalf("generated ID %q is not 16 hex-encoded bytes", got)
+				}
+			}
+		})
+	}
+}
+
+func TestRequestIDFromContextMissing(t *testing.T) {
+	if id, ok := RequestIDFromContext(context.Background()); ok || id != "" {
+		t.Fatalf("RequestIDFromContext = %q, %v, want empty", id, ok)
+	}
+}
+
+func TestHelloLogsRequestID(t *testing.T) {
+	logs := captureLogs(t)
+	req := httptest.NewRequest(http.MethodGet, "/hello", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	RequestIDMiddleware(http.HandlerFunc(helloServer)).ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := logs.entries(t)
+	if len(entries) != 1 || entries[0]["msg"] != "hello" || entries[0]["request_id"] != "abc-123" {
+		t.Fatalf("hello log = %v", entries)
+	}
+}
@@ -0,0 +1,66 @@
+package server
+
+// ShutdownPhase Run关闭过程中所处的阶段，按下面的顺序依次推进，不会倒退
+type ShutdownPhase int32
+
+const (
+	// PhaseRunning 正常提供服务
+	PhaseRunning ShutdownPhase = iota
+	// PhaseNotReady /healthz和/readyz开始返回503，让负载均衡摘掉流量
+	PhaseNotReady
+	// PhaseStopAccepting 关闭监听socket和keep-alive，不再接收新连接和新请求
+	PhaseStopAccepting
+	// PhaseDraining 等正在处理的请求结束，最多等grace时间
+	PhaseDraining
+	// PhaseHooks 执行OnShutdown注册的回调，关闭数据库连接池、缓存等
+	PhaseHooks
+	// PhaseStopped 关闭完成，Run即将返回
+	PhaseStopped
+)
+
+var phaseNames = [...]string{
+	PhaseRunning:       "running",
+	PhaseNotReady:      "not_ready",
+	PhaseStopAccepting: "stop_accepting",
+	PhaseDraining:      "draining",
+	PhaseHooks:         "hooks",
+	PhaseStopped:       "stopped",
+}
+
+func (p ShutdownPhase) String() string {
+	if p >= 0 && int(p) < len(phaseNames) {
+		return phaseNames[p]
+	}
+	return "unknown"
+}
+
+// WithPhaseHook 关闭过程进入每个阶段时调用fn，用于观察关闭流程
+// fn在关闭流程的goroutine里同步调用，应该尽快返回
+func WithPhaseHook(fn func(ShutdownPhase)) Option {
+	return func(c *config) {
+		c.phaseHook = fn
+	}
+}
+
+// Phase 当前所处的阶段
+func (s *Server) Phase() ShutdownPhase {
+	s.phaseMu.Lock()
+	defer s.phaseMu.Unlock()
+	return s.phase
+}
+
+// advancePhase 推进到p，已经到达或越过p时什么都不做
+// 信号goroutine和关闭goroutine都会推进阶段，加锁保证回调按顺序、每个阶段只调用一次
+func (s *Server) advancePhase(p ShutdownPhase) {
+	s.phaseMu.Lock()
+	defer s.phaseMu.Unlock()
+	for s.phase < p {
+		s.phase++
+		if s.phase == PhaseNotReady {
+			s.shuttingDown.Store(true)
+		}
+		if s.cfg.phaseHook != nil {
+			s.cfg.phaseHook(s.phase)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestShutdownPhaseOrder(t *testing.T) {
+	var mu sync.Mutex
+	var events []string
+	record := func(e string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+	}
+	draining := make(chan struct{})
+	s := NewServer(freeAddr(t), WithPhaseHook(func(p ShutdownPhase) {
+		record(p.String())
+		if p == PhaseDraining {
+			close(draining)
+		}
+	}))
+	// 请求一直处理到进入draining阶段才结束，用来确认回调在请求排空之后执行
+	entered := make(chan struct{})
+	s.Handle("/slow", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		close(entered)
+		<-draining
+		record("request done")
+	}))
+	s.OnShutdown(func(ctx context.Context) error {
+		record("hook")
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	base, errc := startServer(t, ctx, s)
+	if p := s.Phase(); p != PhaseRunning {
+		t.Fatalf("phase while serving = %v, want %v", p, PhaseRunning)
+	}
+	reqDone := make(chan struct{})
+	go func() {
+		defer close(reqDone)
+		if resp, err := http.Get(base + "/slow"); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-entered
+	cancel()
+	if err := waitRun(t, errc); err != nil {
+		t.Fatalf("Run = %v, want nil", err)
+	}
+	<-reqDone
+
+	want := []string{"not_ready", "stop_accepting", "draining", "request done", "hooks", "hook", "stopped"}
+	mu.Lock()
+	defer mu.Unlock()
+	if !slices.Equal(events, want) {
+		t.Fatalf("shutdown events = %v, want %v", events, want)
+	}
+	if p := s.Phase(); p != PhaseStopped {
+		t.Fatalf("phase after Run = %v, want %v", p, PhaseStopped)
+	}
+}
+
+func TestAdvancePhase(t *testing.T) {
+	var seen []ShutdownPhase
+	s := NewServer("", WithPhaseHook(func(p ShutdownPhase) { seen = append(seen, p) }))
+	// 跳过的阶段也要依次通知，已经越过的阶段不会倒退
+	s.advancePhase(PhaseDraining)
+	s.advancePhase(PhaseNotReady)
+	s.advancePhase(PhaseDraining)
+	want := []ShutdownPhase{PhaseNotReady, PhaseStopAccepting, PhaseDraining}
+	if !slices.Equal(seen, want) {
+		t.Fatalf("hook saw %v, want %v", seen, want)
+	}
+	if !s.shuttingDown.Load() {
+		t.Fatal("shuttingDown not set after PhaseNotReady")
+	}
+	if got := ShutdownPhase(42).String(); got != "unknown" {
+		t.Fatalf("String of unknown phase = %q", got)
+	}
+}
@@ -0,0 +1,36 @@
+package server
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+)
+
+// MountPprof 在mux上挂载/debug/pprof/，请求需要带Authorization: Bearer <token>
+// token为空时所有请求都返回401，避免误把pprof暴露出去
+func MountPprof(mux *http.ServeMux, token string) {
+	auth := bearerAuth(token)
+	// Index同时处理/debug/pprof/heap、/debug/pprof/goroutine等命名profile
+	mux.Handle("/debug/pprof/", auth(http.HandlerFunc(pprof.Index)))
+	mux.Handle("/debug/pprof/cmdline", auth(http.HandlerFunc(pprof.Cmdline)))
+	mux.Handle("/debug/pprof/profile", auth(http.HandlerFunc(pprof.Profile)))
+	mux.Handle("/debug/pprof/symbol", auth(http.HandlerFunc(pprof.Symbol)))
+	mux.Handle("/debug/pprof/trace", auth(http.HandlerFunc(pprof.Trace)))
+}
+
+// bearerAuth 校验Bearer token，不匹配返回401
+func bearerAuth(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			got, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+			// 用常量时间比较，防止通过响应时间猜token
+			if token == "" || !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="pprof"`)
+				writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testPprofToken = "s3cret"
+
+// pprofRequest 用带pprof的mux处理一个请求，auth为空时不带Authorization
+func pprofRequest(t *testing.T, target, auth string) *httptest.ResponseRecorder {
+	t.Helper()
+	mux := http.NewServeMux()
+	MountPprof(mux, testPprofToken)
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestPprofUnauthorized(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		auth   string
+	}{
+		{name: "no token", target: "/debug/pprof/goroutine"},
+		{name: "wrong token", target: "/debug/pprof/goroutine", auth: "Bearer nope"},
+		{name: "wrong scheme", target: "/debug/pprof/goroutine", auth: "Basic " + testPprofToken},
+		{name: "index", target: "/debug/pprof/"},
+		{name: "cmdline", target: "/debug/pprof/cmdline"},
+		{name: "trace", target: "/debug/pprof/trace"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := pprofRequest(t, tt.target, tt.auth)
+			if rec.Code != http.StatusUnauthorized {
+				t.Fatalf("status = %d, want 401", rec.Code)
+			}
+			if rec.Header().Get("WWW-Authenticate") == "" {
+				t.Fatal("no WWW-Authenticate header")
+			}
+		})
+	}
+}
+
+func TestPprofEmptyTokenRejectsAll(t *testing.T) {
+	mux := http.NewServeMux()
+	MountPprof(mux, "")
+	req := httptest.NewRequest(http.MethodGet, "/debug/pprof/goroutine", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", rec.Code)
+	}
+}
+
+func TestPprofAuthorized(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		wantCode int
+		wantBody string
+	}{
+		{name: "goroutine", target: "/debug/pprof/goroutine?debug=1", wantCode: http.StatusOK, wantBody: "goroutine profile:"},
+		{name: "heap", target: "/debug/pprof/heap?debug=1&gc=1", wantCode: http.StatusOK, wantBody: "heap profile:"},
+		{name: "index", target: "/debug/pprof/", wantCode: http.StatusOK, wantBody: "goroutine"},
+		{name: "cmdline", target: "/debug/pprof/cmdline", wantCode: http.StatusOK, wantBody: ".test"},
+		{name: "trace", target: "/debug/pprof/trace?seconds=0.05", wantCode: http.StatusOK},
+		{name: "unknown profile", target: "/debug/pprof/nope", wantCode: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := pprofRequest(t, tt.target, "Bearer "+testPprofToken)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+			}
+			if tt.wantCode == http.StatusOK && rec.Body.Len() == 0 {
+				t.Fatal("empty body")
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Fatalf("body does not contain %q", tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestPprofSymbol(t *testing.T) {
+	mux := http.NewServeMux()
+	MountPprof(mux, testPprofToken)
+	// 当前函数里的地址，应该能解析回函数名
+	pc, _, _, _ := runtime.Caller(0)
+	req := httptest.NewRequest(http.MethodPost, "/debug/pprof/symbol", strings.NewReader(fmt.Sprintf("%#x", pc)))
+	req.Header.Set("Authorization", "Bearer "+testPprofToken)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "TestPprofSymbol") {
+		t.Fatalf("symbol not resolved: %q", rec.Body)
+	}
+}
+
+// 通过真实的Server采集CPU profile，采集时间比WriteTimeout长时响应也不能被截断
+func TestPprofProfileThroughServer(t *testing.T) {
+	s := NewServer(freeAddr(t), WithPprof(testPprofToken), WithWriteTimeout(500*time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	base, errc := startServer(t, ctx, s)
+	defer func() {
+		cancel()
+		waitRun(t, errc)
+	}()
+
+	req, err := http.NewRequest(http.MethodGet, base+"/debug/pprof/profile?seconds=1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+testPprofToken)
+	resp, err := testClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading profile: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", resp.StatusCode, body)
+	}
+	// CPU profile是gzip压缩的protobuf
+	if len(body) < 2 || body[0] != 0x1f || body[1] != 0x8b {
+		t.Fatalf("body is not a gzipped profile (%d bytes)", len(body))
+	}
+}
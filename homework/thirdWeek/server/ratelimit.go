@@ -0,0 +1,133 @@
+package server
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gostudy/channel"
+)
+
+// 某个IP超过这么久没有请求，就回收它的令牌桶
+const ipLimiterIdle = 3 * time.Minute
+
+// IPRateLimitOption IPRateLimitMiddleware的可选配置
+type IPRateLimitOption func(*ipRateLimiter)
+
+// TrustForwardedFor 服务部署在可信代理后面时使用，按X-Forwarded-For识别客户端IP
+// 没有设置时只看RemoteAddr，避免客户端伪造请求头绕过限流
+func TrustForwardedFor() IPRateLimitOption {
+	return func(l *ipRateLimiter) {
+		l.trustProxy = true
+	}
+}
+
+// ipLimiterEntry 一个IP的令牌桶和最近一次请求的时间
+// 令牌桶是lazy模式的，不起goroutine，IP再多也只占内存
+type ipLimiterEntry struct {
+	limiter  *channel.RateLimiter
+	lastSeen time.Time
+}
+
+type ipRateLimiter struct {
+	interval   time.Duration
+	burst      int
+	trustProxy bool
+	now        func() time.Time
+
+	mu        sync.Mutex
+	entries   map[string]*ipLimiterEntry
+	lastSweep time.Time
+}
+
+// IPRateLimitMiddleware 按客户端IP限流，每个IP每秒rps个请求，最多攒burst个
+// 超过限制返回429并带上Retry-After；空闲超过ipLimiterIdle的IP会被回收
+func IPRateLimitMiddleware(rps float64, burst int, opts ...IPRateLimitOption) func(http.Handler) http.Handler {
+	if rps <= 0 {
+		rps = 1
+	}
+	l := newIPRateLimiter(rps, burst, opts...)
+	retryAfter := strconv.Itoa(int(math.Max(1, math.Ceil(1/rps))))
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if !l.allow(l.clientIP(req)) {
+				w.Header().Set("Retry-After", retryAfter)
+				writeJSONError(w, http.StatusTooManyRequests, "too many requests")
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// newIPRateLimiter rps<=0按1处理，burst<1按1处理
+func newIPRateLimiter(rps float64, burst int, opts ...IPRateLimitOption) *ipRateLimiter {
+	if rps <= 0 {
+		rps = 1
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	l := &ipRateLimiter{
+		interval:  time.Duration(float64(time.Second) / rps),
+		burst:     burst,
+		now:       time.Now,
+		entries:   make(map[string]*ipLimiterEntry),
+		lastSweep: time.Now(),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// allow 取出ip对应的令牌桶并尝试拿一个令牌，顺便回收空闲的IP
+func (l *ipRateLimiter) allow(ip string) bool {
+	now := l.now()
+	l.mu.Lock()
+	if now.Sub(l.lastSweep) > ipLimiterIdle {
+		l.sweep(now)
+	}
+	e, ok := l.entries[ip]
+	if !ok {
+		e = &ipLimiterEntry{limiter: channel.NewLazyRateLimiter(l.interval, l.burst)}
+		l.entries[ip] = e
+	}
+	e.lastSeen = now
+	l.mu.Unlock()
+	return e.limiter.Allow()
+}
+
+// sweep 删除空闲的令牌桶，调用时需持有l.mu
+// 空闲的时间还不够把空桶补满的不删，否则客户端等ipLimiterIdle就能拿到满桶
+func (l *ipRateLimiter) sweep(now time.Time) {
+	refill := l.interval * time.Duration(l.burst)
+	for ip, e := range l.entries {
+		if idle := now.Sub(e.lastSeen); idle > ipLimiterIdle && idle >= refill {
+			e.limiter.Close()
+			delete(l.entries, ip)
+		}
+	}
+	l.lastSweep = now
+}
+
+// clientIP 信任代理时取X-Forwarded-For里最后一个地址（由最近的代理追加），否则取RemoteAddr
+func (l *ipRateLimiter) clientIP(req *http.Request) string {
+	if l.trustProxy {
+		if xff := req.Header.Get("X-Forwarded-For"); xff != "" {
+			parts := strings.Split(xff, ",")
+			if ip := strings.TrimSpace(parts[len(parts)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestIPRateLimitMiddleware(t *testing.T) {
+	h := IPRateLimitMiddleware(1, 2)(http.HandlerFunc(helloServer))
+	do := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/hello", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// 同一个IP不同端口算一个客户端，burst用完后返回429
+	for i, addr := range []string{"10.0.0.1:1000", "10.0.0.1:2000"} {
+		if rec := do(addr); rec.Code != http.StatusOK {
+			t.Fatalf("request %d = %d, want 200", i, rec.Code)
+		}
+	}
+	rec := do("10.0.0.1:3000")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("request over burst = %d, want 429", rec.Code)
+	}
+	if ra := rec.Header().Get("Retry-After"); ra != "1" {
+		t.Fatalf("Retry-After = %q, want 1", ra)
+	}
+	// 其他IP不受影响
+	if rec := do("10.0.0.2:1000"); rec.Code != http.StatusOK {
+		t.Fatalf("other IP = %d, want 200", rec.Code)
+	}
+}
+
+// fakeClock 测试里手动推进的时间，只影响记录的最近请求时间和回收
+func fakeClock(l *ipRateLimiter) *time.Time {
+	now := time.Now()
+	l.now = func() time.Time { return now }
+	return &now
+}
+
+func TestIPRateLimiterSweep(t *testing.T) {
+	l := newIPRateLimiter(1, 1)
+	now := fakeClock(l)
+	l.allow("idle")
+	*now = now.Add(ipLimiterIdle + time.Second)
+	l.lastSweep = *now
+	l.allow("active")
+	*now = now.Add(time.Second)
+	l.sweep(*now)
+	if _, ok := l.entries["idle"]; ok {
+		t.Fatal("idle bucket not removed")
+	}
+	if _, ok := l.entries["active"]; !ok {
+		t.Fatal("active bucket removed")
+	}
+
+	// 补满要很久的桶空闲了也不能删，否则客户端等ipLimiterIdle就能拿到满桶
+	slow := newIPRateLimiter(0.001, 5)
+	now = fakeClock(slow)
+	slow.allow("slow")
+	*now = now.Add(ipLimiterIdle + time.Second)
+	slow.sweep(*now)
+	if _, ok := slow.entries["slow"]; !ok {
+		t.Fatal("bucket removed before it refilled")
+	}
+}
+
+func TestIPRateLimiterNoGoroutines(t *testing.T) {
+	before := runtime.NumGoroutine()
+	// 每个IP的令牌桶都不起goroutine，rps很大也不会起ticker空转
+	l := newIPRateLimiter(1e9, 10)
+	for i := 0; i < 1000; i++ {
+		if !l.allow("10.0.0." + strconv.Itoa(i)) {
+			t.Fatal("first request rejected")
+		}
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Fatalf("goroutines = %d after 1000 IPs, want <= %d", n, before)
+	}
+}
+
+func TestIPRateLimiterClientIP(t *testing.T) {
+	tests := []struct {
+		name  string
+		trust bool
+		xff   string
+		want  string
+	}{
+		{name: "remote addr", xff: "1.1.1.1", want: "10.0.0.1"},
+		{name: "trusted proxy", trust: true, xff: "1.1.1.1, 2.2.2.2", want: "2.2.2.2"},
+		{name: "trusted without header", trust: true, want: "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []IPRateLimitOption
+			if tt.trust {
+				opts = append(opts, TrustForwardedFor())
+			}
+			l := newIPRateLimiter(1, 1, opts...)
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = "10.0.0.1:1234"
+			if tt.xff != "" {
+				req.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			if got := l.clientIP(req); got != tt.want {
+				t.Fatalf("clientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
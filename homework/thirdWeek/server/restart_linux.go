@@ -0,0 +1,68 @@
+//go:build linux
+
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+// listenerFDEnv 热重启时告诉子进程继承的监听socket是哪个fd
+const listenerFDEnv = "GOSTUDY_LISTENER_FD"
+
+// restartSignals 收到这些信号时热重启
+var restartSignals = []os.Signal{syscall.SIGHUP}
+
+// gracefulRestart 重新启动当前程序，并把监听socket交给子进程
+// 子进程启动后在同一个端口上accept，父进程随后优雅关闭、处理完剩下的请求
+func gracefulRestart(ln net.Listener) error {
+	fl, ok := ln.(interface{ File() (*os.File, error) })
+	if !ok {
+		return fmt.Errorf("graceful restart: listener %T has no file", ln)
+	}
+	// File返回的是dup出来的fd，父进程关闭自己的listener不影响子进程
+	f, err := fl.File()
+	if err != nil {
+		return fmt.Errorf("graceful restart: %w", err)
+	}
+	defer f.Close()
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("graceful restart: %w", err)
+	}
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	// ExtraFiles里的第一个文件在子进程里是fd 3（前面是stdin/stdout/stderr）
+	cmd.ExtraFiles = []*os.File{f}
+	cmd.Env = append(os.Environ(), listenerFDEnv+"=3")
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("graceful restart: %w", err)
+	}
+	logger.Info("graceful restart", slog.Int("child_pid", cmd.Process.Pid))
+	return nil
+}
+
+// inheritedListener 如果当前进程是gracefulRestart启动的，返回继承来的socket，否则返回nil
+func inheritedListener() (net.Listener, error) {
+	v := os.Getenv(listenerFDEnv)
+	if v == "" {
+		return nil, nil
+	}
+	// 只继承一次，下次热重启时再由gracefulRestart重新设置
+	os.Unsetenv(listenerFDEnv)
+	fd, err := strconv.Atoi(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q: %w", listenerFDEnv, v, err)
+	}
+	f := os.NewFile(uintptr(fd), "inherited-listener")
+	defer f.Close()
+	return net.FileListener(f)
+}
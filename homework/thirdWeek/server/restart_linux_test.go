@@ -0,0 +1,123 @@
+//go:build linux
+
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// restartChildEnv 设置了这个环境变量时，测试二进制作为热重启的子进程运行TestGracefulRestartChild
+const restartChildEnv = "GOSTUDY_RESTART_CHILD"
+
+// TestGracefulRestartChild 不单独运行，由TestGracefulRestart通过gracefulRestart启动
+// 在继承来的socket上服务一个请求后退出
+func TestGracefulRestartChild(t *testing.T) {
+	if os.Getenv(restartChildEnv) == "" {
+		t.Skip("only runs as the child of TestGracefulRestart")
+	}
+	ln, err := inheritedListener()
+	if err != nil || ln == nil {
+		t.Fatalf("inheritedListener = %v, %v", ln, err)
+	}
+	served := make(chan struct{}, 1)
+	go http.Serve(ln, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		io.WriteString(w, "child")
+		select {
+		case served <- struct{}{}:
+		default:
+		}
+	}))
+	select {
+	case <-served:
+		// 让响应发出去再退出
+		time.Sleep(100 * time.Millisecond)
+	case <-time.After(10 * time.Second):
+	}
+}
+
+func TestGracefulRestart(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := "http://" + ln.Addr().String()
+	release := make(chan struct{})
+	entered := make(chan struct{}, 1)
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		// 只有/slow阻塞，重启后还落到父进程上的请求直接返回，由下面的循环重试
+		if req.URL.Path == "/slow" {
+			entered <- struct{}{}
+			<-release
+		}
+		io.WriteString(w, "parent")
+	})}
+	go srv.Serve(ln)
+
+	// 父进程上有一个正在处理的请求
+	inflight := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(url + "/slow")
+		if err != nil {
+			inflight <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		inflight <- string(b)
+	}()
+	<-entered
+
+	// 子进程是只运行TestGracefulRestartChild的测试二进制，输出丢掉，免得混进当前测试的输出
+	t.Setenv(restartChildEnv, "1")
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+	args, stdout, stderr := os.Args, os.Stdout, os.Stderr
+	os.Args = []string{args[0], "-test.run=^TestGracefulRestartChild$"}
+	os.Stdout, os.Stderr = devNull, devNull
+	err = gracefulRestart(ln)
+	os.Args, os.Stdout, os.Stderr = args, stdout, stderr
+	if err != nil {
+		t.Fatalf("gracefulRestart = %v", err)
+	}
+
+	// 父进程停止accept，开始排空
+	shutdownDone := make(chan error, 1)
+	go func() { shutdownDone <- srv.Shutdown(context.Background()) }()
+
+	// 新连接由子进程在同一个端口上处理
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}, Timeout: time.Second}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := client.Get(url)
+		if err == nil {
+			b, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if string(b) == "child" {
+				break
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("child did not accept on %s: %v", url, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// 父进程上的请求仍然正常处理完
+	close(release)
+	if body := <-inflight; !strings.Contains(body, "parent") {
+		t.Fatalf("in-flight request got %q, want parent", body)
+	}
+	if err := <-shutdownDone; err != nil {
+		t.Fatalf("Shutdown = %v", err)
+	}
+}
@@ -0,0 +1,20 @@
+//go:build !linux
+
+package server
+
+import (
+	"errors"
+	"net"
+	"os"
+)
+
+// restartSignals 其他平台不支持热重启，不注册信号
+var restartSignals []os.Signal
+
+func gracefulRestart(ln net.Listener) error {
+	return errors.New("graceful restart is only supported on linux")
+}
+
+func inheritedListener() (net.Listener, error) {
+	return nil, nil
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Router 按方法和路径匹配路由，路径里的{name}段会被提取出来，用Param读取
+// 例如 r.Handle(http.MethodGet, "/users/{id}", h)，h里Param(req, "id")得到id
+// 路径能匹配但方法不对时返回405并带上Allow，都匹配不上返回404
+type Router struct {
+	routes []route
+}
+
+type route struct {
+	method   string
+	segments []string
+	handler  http.HandlerFunc
+}
+
+// NewRouter 创建空的Router
+func NewRouter() *Router {
+	return &Router{}
+}
+
+// Handle 注册路由，按注册顺序匹配，需要在开始处理请求之前调用
+func (r *Router) Handle(method, pattern string, h http.HandlerFunc) {
+	r.routes = append(r.routes, route{
+		method:   method,
+		segments: splitPath(pattern),
+		handler:  h,
+	})
+}
+
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	segments := splitPath(req.URL.Path)
+	var allowed []string
+	for _, rt := range r.routes {
+		params, ok := rt.match(segments)
+		if !ok {
+			continue
+		}
+		if rt.method != req.Method {
+			allowed = append(allowed, rt.method)
+			continue
+		}
+		if len(params) > 0 {
+			req = req.WithContext(context.WithValue(req.Context(), paramsKey, params))
+		}
+		rt.handler(w, req)
+		return
+	}
+	if len(allowed) > 0 {
+		// 多个路由可能注册了同一个方法，排序后去重
+		sort.Strings(allowed)
+		allowed = slices.Compact(allowed)
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSONError(w, http.StatusNotFound, "not found")
+}
+
+// match 段数相同，并且除{name}以外的段都相等时匹配，返回提取出来的参数
+func (rt route) match(segments []string) (map[string]string, bool) {
+	if len(segments) != len(rt.segments) {
+		return nil, false
+	}
+	var params map[string]string
+	for i, seg := range rt.segments {
+		if name, ok := paramName(seg); ok {
+			if params == nil {
+				params = make(map[string]string)
+			}
+			params[name] = segments[i]
+			continue
+		}
+		if seg != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// paramName seg是{name}的形式时返回name
+func paramName(seg string) (string, bool) {
+	if len(seg) > 2 && strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+		return seg[1 : len(seg)-1], true
+	}
+	return "", false
+}
+
+// splitPath 去掉首尾的/后按/切分，"/"切出来是空列表
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+// Param 返回Router从路径里提取的参数，没有时返回空字符串
+func Param(req *http.Request, name string) string {
+	params, _ := req.Context().Value(paramsKey).(map[string]string)
+	return params[name]
+}
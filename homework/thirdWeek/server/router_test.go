@@ -0,0 +1,74 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouter(t *testing.T) {
+	r := NewRouter()
+	r.Handle(http.MethodGet, "/users/{id}", func(w http.ResponseWriter, req *http.Request) {
+		io.WriteString(w, "get user "+Param(req, "id"))
+	})
+	r.Handle(http.MethodDelete, "/users/{id}", func(w http.ResponseWriter, req *http.Request) {
+		io.WriteString(w, "delete user "+Param(req, "id"))
+	})
+	r.Handle(http.MethodGet, "/users/{id}/posts/{post}", func(w http.ResponseWriter, req *http.Request) {
+		io.WriteString(w, Param(req, "id")+"/"+Param(req, "post")+Param(req, "missing"))
+	})
+	// 和/users/{id}重叠的路由，405时同一个方法只能出现一次
+	r.Handle(http.MethodGet, "/users/me", func(w http.ResponseWriter, req *http.Request) {
+		io.WriteString(w, "me")
+	})
+	r.Handle(http.MethodPut, "/users/me", func(w http.ResponseWriter, req *http.Request) {
+		io.WriteString(w, "put me")
+	})
+	r.Handle(http.MethodGet, "/", func(w http.ResponseWriter, req *http.Request) {
+		io.WriteString(w, "root")
+	})
+
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		wantCode  int
+		wantBody  string
+		wantAllow string
+	}{
+		{name: "param", method: http.MethodGet, path: "/users/42", wantCode: http.StatusOK, wantBody: "get user 42"},
+		{name: "trailing slash", method: http.MethodGet, path: "/users/42/", wantCode: http.StatusOK, wantBody: "get user 42"},
+		{name: "other method", method: http.MethodDelete, path: "/users/7", wantCode: http.StatusOK, wantBody: "delete user 7"},
+		// 没有注册的参数返回空字符串
+		{name: "two params", method: http.MethodGet, path: "/users/42/posts/9", wantCode: http.StatusOK, wantBody: "42/9"},
+		{name: "root", method: http.MethodGet, path: "/", wantCode: http.StatusOK, wantBody: "root"},
+		{name: "method mismatch", method: http.MethodPost, path: "/users/42", wantCode: http.StatusMethodNotAllowed, wantAllow: "DELETE, GET"},
+		{name: "overlapping routes", method: http.MethodPost, path: "/users/me", wantCode: http.StatusMethodNotAllowed, wantAllow: "DELETE, GET, PUT"},
+		{name: "overlapping method", method: http.MethodPut, path: "/users/me", wantCode: http.StatusOK, wantBody: "put me"},
+		{name: "no match", method: http.MethodGet, path: "/users", wantCode: http.StatusNotFound},
+		{name: "too long", method: http.MethodGet, path: "/users/42/posts", wantCode: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+				t.Fatalf("body = %q, want %q", rec.Body, tt.wantBody)
+			}
+			if allow := rec.Header().Get("Allow"); allow != tt.wantAllow {
+				t.Fatalf("Allow = %q, want %q", allow, tt.wantAllow)
+			}
+		})
+	}
+}
+
+func TestParamWithoutRouter(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if got := Param(req, "id"); got != "" {
+		t.Fatalf("Param = %q, want empty", got)
+	}
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// serverRunner Run里errgroup的编排逻辑：提供服务、等待信号、收到信号后关闭
+// 监听的socket、信号来源和关闭函数都由外面传进来，测试时不需要真实的socket和系统信号
+type serverRunner struct {
+	ln net.Listener
+	// serve 在ln上提供服务，直到shutdown被调用；返回http.ErrServerClosed视为正常退出
+	serve func(ln net.Listener) error
+	// shutdown 在ctx结束（信号、出错或者调用方取消）后调用一次
+	shutdown func() error
+	// background 和服务一起运行的其他任务，ctx结束时应该返回
+	background []func(ctx context.Context) error
+
+	// stop 收到信号后开始关闭
+	stop <-chan os.Signal
+	// restart 收到信号后调用onRestart，成功则开始关闭，由新进程接手
+	restart   <-chan os.Signal
+	onRestart func() error
+	// levelUp/levelDown 在Debug和Info之间切换日志级别
+	levelUp   <-chan os.Signal
+	levelDown <-chan os.Signal
+	// onShuttingDown 开始关闭时最先调用，比如摘掉readiness
+	onShuttingDown func()
+}
+
+// run 阻塞直到所有goroutine退出，返回第一个错误；正常关闭时返回nil
+func (r *serverRunner) run(ctx context.Context) error {
+	// 定义WithCancel，信号到来时通过cancel通知其他goroutine
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// 使用errgroup进行goroutine取消
+	group, errCtx := errgroup.WithContext(ctx)
+
+	group.Go(func() error {
+		err := r.serve(r.ln)
+		// Shutdown之后Serve返回ErrServerClosed，属于正常退出
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	})
+
+	for _, fn := range r.background {
+		group.Go(func() error {
+			return fn(errCtx)
+		})
+	}
+
+	group.Go(func() error {
+		<-errCtx.Done()
+		return r.shutdown()
+	})
+
+	group.Go(func() error {
+		for {
+			select {
+			case <-errCtx.Done():
+				r.shuttingDown()
+				return nil
+			case <-r.stop:
+				// 先摘掉readiness，再通知其他goroutine关闭
+				r.shuttingDown()
+				cancel()
+				return nil
+			case <-r.levelUp:
+				logLevel.Set(slog.LevelDebug)
+				logger.Info("log level changed", slog.String("level", logLevel.Level().String()))
+			case <-r.levelDown:
+				logLevel.Set(slog.LevelInfo)
+				logger.Info("log level changed", slog.String("level", logLevel.Level().String()))
+			case <-r.restart:
+				if err := r.onRestart(); err != nil {
+					logger.Error("graceful restart", slog.Any("error", err))
+					continue
+				}
+				// 子进程已经在同一个socket上accept了，父进程走正常的关闭流程把请求处理完
+				r.shuttingDown()
+				cancel()
+				return nil
+			}
+		}
+	})
+
+	return group.Wait()
+}
+
+func (r *serverRunner) shuttingDown() {
+	if r.onShuttingDown != nil {
+		r.onShuttingDown()
+	}
+}
+
+// notifySignals 注册sigs，返回接收信号的channel和取消注册的函数
+// sigs为空时不注册，返回nil channel，select永远不会选中；
+// 不能把空列表传给signal.Notify，那样会收到所有信号（比如SIGURG、SIGCHLD）
+func notifySignals(sigs ...os.Signal) (<-chan os.Signal, func()) {
+	if len(sigs) == 0 {
+		return nil, func() {}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	return ch, func() {
+		signal.Stop(ch)
+	}
+}
@@ -0,0 +1,177 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestNotifySignalsEmpty(t *testing.T) {
+	// 空列表不能交给signal.Notify，否则会收到所有信号
+	ch, stop := notifySignals()
+	defer stop()
+	if ch != nil {
+		t.Fatal("notifySignals() returned a non-nil channel")
+	}
+}
+
+// fakeRunner 不用真实socket和信号的serverRunner，serve一直阻塞到shutdown被调用
+type fakeRunner struct {
+	serverRunner
+	stop      chan os.Signal
+	restart   chan os.Signal
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	shutdowns    atomic.Int32
+	shuttingDown atomic.Int32
+}
+
+func newFakeRunner(serveErr, shutdownErr error) *fakeRunner {
+	f := &fakeRunner{
+		stop:    make(chan os.Signal, 1),
+		restart: make(chan os.Signal, 1),
+		closed:  make(chan struct{}),
+	}
+	f.serverRunner = serverRunner{
+		serve: func(net.Listener) error {
+			if serveErr != nil {
+				return serveErr
+			}
+			<-f.closed
+			return http.ErrServerClosed
+		},
+		shutdown: func() error {
+			f.shutdowns.Add(1)
+			f.closeOnce.Do(func() { close(f.closed) })
+			return shutdownErr
+		},
+		stop:           f.stop,
+		restart:        f.restart,
+		onShuttingDown: func() { f.shuttingDown.Add(1) },
+	}
+	return f
+}
+
+// runAsync 在后台执行run，返回接收结果的channel
+func (f *fakeRunner) runAsync(ctx context.Context) <-chan error {
+	errc := make(chan error, 1)
+	go func() { errc <- f.run(ctx) }()
+	return errc
+}
+
+func TestRunnerStopSignal(t *testing.T) {
+	f := newFakeRunner(nil, nil)
+	errc := f.runAsync(context.Background())
+	f.stop <- syscall.SIGTERM
+
+	if err := waitRun(t, errc); err != nil {
+		t.Fatalf("run = %v, want nil", err)
+	}
+	if n := f.shutdowns.Load(); n != 1 {
+		t.Fatalf("shutdown called %d times, want 1", n)
+	}
+	if f.shuttingDown.Load() == 0 {
+		t.Fatal("onShuttingDown not called")
+	}
+}
+
+func TestRunnerErrors(t *testing.T) {
+	errServe := errors.New("listen failed")
+	errShutdown := errors.New("shutdown timed out")
+	errTask := errors.New("background failed")
+	tests := []struct {
+		name        string
+		serveErr    error
+		shutdownErr error
+		taskErr     error
+		want        error
+	}{
+		// serve出错时其他goroutine也要被取消，shutdown照样调用
+		{name: "serve error", serveErr: errServe, want: errServe},
+		{name: "shutdown error", shutdownErr: errShutdown, want: errShutdown},
+		{name: "background error", taskErr: errTask, want: errTask},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeRunner(tt.serveErr, tt.shutdownErr)
+			if tt.taskErr != nil {
+				f.background = append(f.background, func(context.Context) error { return tt.taskErr })
+			}
+			errc := f.runAsync(context.Background())
+			if tt.serveErr == nil && tt.taskErr == nil {
+				f.stop <- syscall.SIGINT
+			}
+			if err := waitRun(t, errc); !errors.Is(err, tt.want) {
+				t.Fatalf("run = %v, want %v", err, tt.want)
+			}
+			if n := f.shutdowns.Load(); n != 1 {
+				t.Fatalf("shutdown called %d times, want 1", n)
+			}
+		})
+	}
+}
+
+func TestRunnerParentCancel(t *testing.T) {
+	f := newFakeRunner(nil, nil)
+	var bgDone atomic.Bool
+	f.background = append(f.background, func(ctx context.Context) error {
+		<-ctx.Done()
+		bgDone.Store(true)
+		return nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := f.runAsync(ctx)
+	cancel()
+
+	if err := waitRun(t, errc); err != nil {
+		t.Fatalf("run = %v, want nil", err)
+	}
+	if f.shutdowns.Load() != 1 || f.shuttingDown.Load() == 0 {
+		t.Fatal("caller cancel did not shut down the server")
+	}
+	if !bgDone.Load() {
+		t.Fatal("background task not cancelled")
+	}
+}
+
+func TestRunnerRestartSignal(t *testing.T) {
+	f := newFakeRunner(nil, nil)
+	var restarts atomic.Int32
+	f.onRestart = func() error {
+		// 第一次热重启失败，服务要继续运行
+		if restarts.Add(1) == 1 {
+			return errors.New("fork failed")
+		}
+		return nil
+	}
+	errc := f.runAsync(context.Background())
+
+	f.restart <- syscall.SIGHUP
+	select {
+	case err := <-errc:
+		t.Fatalf("run returned %v after a failed restart", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	if n := f.shutdowns.Load(); n != 0 {
+		t.Fatalf("shutdown called %d times after a failed restart", n)
+	}
+
+	f.restart <- syscall.SIGHUP
+	if err := waitRun(t, errc); err != nil {
+		t.Fatalf("run = %v, want nil", err)
+	}
+	if n := restarts.Load(); n != 2 {
+		t.Fatalf("onRestart called %d times, want 2", n)
+	}
+	if n := f.shutdowns.Load(); n != 1 {
+		t.Fatalf("shutdown called %d times, want 1", n)
+	}
+}
@@ -0,0 +1,587 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"gostudy/channel"
+)
+
+const (
+	// 默认优雅关闭等待时间
+	defaultShutdownGrace = 10 * time.Second
+	// 默认监听地址
+	defaultAddr = ":8080"
+	// 默认路由
+	defaultPattern = "/hello"
+
+	// 默认超时时间，防止慢连接（slowloris）一直占着连接
+	defaultReadHeaderTimeout = 5 * time.Second
+	defaultReadTimeout       = 10 * time.Second
+	defaultWriteTimeout      = 10 * time.Second
+	defaultIdleTimeout       = 30 * time.Second
+)
+
+// Option 用来修改Server的配置
+type Option func(*config)
+
+type config struct {
+	pattern       string
+	shutdownGrace time.Duration
+	signals       []os.Signal
+	certFile      string
+	keyFile       string
+	disableHTTP2  bool
+	corsOrigins   []string
+	maxConns      int
+	certReload    time.Duration
+	maxBodyBytes  int64
+	maxRespBytes  int64
+	pprofToken    string
+	unixSocket    string
+	socketPerm    os.FileMode
+	slowRequest   time.Duration
+	phaseHook     func(ShutdownPhase)
+	staticDir     string
+	staticMaxAge  time.Duration
+
+	readHeaderTimeout time.Duration
+	readTimeout       time.Duration
+	writeTimeout      time.Duration
+	idleTimeout       time.Duration
+}
+
+// WithPattern 修改hello挂载的路由
+func WithPattern(pattern string) Option {
+	return func(c *config) {
+		c.pattern = pattern
+	}
+}
+
+// WithShutdownGrace 修改优雅关闭的等待时间
+func WithShutdownGrace(d time.Duration) Option {
+	return func(c *config) {
+		c.shutdownGrace = d
+	}
+}
+
+// WithSignals 修改触发关闭的信号，默认只处理SIGINT和SIGTERM
+// 传空列表时保留默认值，避免退化成监听所有信号
+func WithSignals(sigs ...os.Signal) Option {
+	return func(c *config) {
+		if len(sigs) > 0 {
+			c.signals = sigs
+		}
+	}
+}
+
+// WithTLS 使用证书启动https，certFile或keyFile为空时仍然是http
+func WithTLS(certFile, keyFile string) Option {
+	return func(c *config) {
+		c.certFile = certFile
+		c.keyFile = keyFile
+	}
+}
+
+// WithCertReload 每隔interval检查一次WithTLS的证书文件，变化后自动加载，不用重启
+func WithCertReload(interval time.Duration) Option {
+	return func(c *config) {
+		c.certReload = interval
+	}
+}
+
+// WithoutHTTP2 关闭https下自动启用的HTTP/2
+func WithoutHTTP2() Option {
+	return func(c *config) {
+		c.disableHTTP2 = true
+	}
+}
+
+// WithCORS 允许这些来源跨域访问，"*"表示任意来源
+func WithCORS(origins ...string) Option {
+	return func(c *config) {
+		c.corsOrigins = origins
+	}
+}
+
+// WithMaxConns 最多同时保持n个连接，n<=0表示不限制
+func WithMaxConns(n int) Option {
+	return func(c *config) {
+		c.maxConns = n
+	}
+}
+
+// WithMaxBodyBytes 所有请求的请求体最多n字节，超过返回413，n<=0表示不限制
+func WithMaxBodyBytes(n int64) Option {
+	return func(c *config) {
+		c.maxBodyBytes = n
+	}
+}
+
+// WithMaxResponseBytes 所有响应最多n字节，超过的部分被截断，n<=0表示不限制
+func WithMaxResponseBytes(n int64) Option {
+	return func(c *config) {
+		c.maxRespBytes = n
+	}
+}
+
+// WithPprof 挂载/debug/pprof/，访问时需要带Bearer token
+func WithPprof(token string) Option {
+	return func(c *config) {
+		c.pprofToken = token
+	}
+}
+
+// WithUnixSocket 监听path上的unix socket而不是TCP地址，适合和sidecar通信
+// 设置后NewServer的addr和环境变量HTTP_ADDR都会被忽略
+func WithUnixSocket(path string) Option {
+	return func(c *config) {
+		c.unixSocket = path
+	}
+}
+
+// WithUnixSocketPerm 修改unix socket文件的权限，默认0660
+func WithUnixSocketPerm(perm os.FileMode) Option {
+	return func(c *config) {
+		c.socketPerm = perm
+	}
+}
+
+// WithSlowRequestLog 处理时间超过threshold的请求打Warn日志，threshold<=0表示不记录
+func WithSlowRequestLog(threshold time.Duration) Option {
+	return func(c *config) {
+		c.slowRequest = threshold
+	}
+}
+
+// WithStatic 在/static/下提供dir目录里的静态文件，浏览器缓存maxAge
+func WithStatic(dir string, maxAge time.Duration) Option {
+	return func(c *config) {
+		c.staticDir = dir
+		c.staticMaxAge = maxAge
+	}
+}
+
+// WithReadHeaderTimeout 修改读取请求头的超时时间
+func WithReadHeaderTimeout(d time.Duration) Option {
+	return func(c *config) {
+		c.readHeaderTimeout = d
+	}
+}
+
+// WithReadTimeout 修改读取整个请求（包括body）的超时时间
+func WithReadTimeout(d time.Duration) Option {
+	return func(c *config) {
+		c.readTimeout = d
+	}
+}
+
+// WithWriteTimeout 修改写响应的超时时间
+func WithWriteTimeout(d time.Duration) Option {
+	return func(c *config) {
+		c.writeTimeout = d
+	}
+}
+
+// WithIdleTimeout 修改keep-alive连接的空闲超时时间
+func WithIdleTimeout(d time.Duration) Option {
+	return func(c *config) {
+		c.idleTimeout = d
+	}
+}
+
+// Server 把http服务、信号处理和优雅关闭用errgroup串起来
+type Server struct {
+	addr string
+	cfg  config
+	srv  *http.Server
+	// 每个Server自己的路由，不用全局的http.DefaultServeMux
+	mux *http.ServeMux
+	// 开始关闭后置为true，/healthz据此返回503
+	shuttingDown atomic.Bool
+	// 关闭流程当前的阶段，见ShutdownPhase
+	phaseMu sync.Mutex
+	phase   ShutdownPhase
+	// 请求计数和延迟，/metrics输出
+	metrics *Metrics
+	// 正在处理的请求数
+	inflight atomic.Int64
+	// OnShutdown注册的回调
+	hooksMu sync.Mutex
+	hooks   []func(ctx context.Context) error
+
+	// RegisterStats注册的子系统，/debug/stats汇总输出
+	statsMu sync.Mutex
+	stats   map[string]StatsProvider
+
+	// /readyz探测的依赖
+	health *HealthChecker
+	// /events推送的消息
+	events *channel.Broker[string]
+}
+
+// NewServer 创建Server，addr为空时的处理见StartHttpServer
+func NewServer(addr string, opts ...Option) *Server {
+	cfg := config{
+		pattern:       defaultPattern,
+		shutdownGrace: defaultShutdownGrace,
+		signals:       []os.Signal{syscall.SIGINT, syscall.SIGTERM},
+		socketPerm:    defaultSocketPerm,
+
+		readHeaderTimeout: defaultReadHeaderTimeout,
+		readTimeout:       defaultReadTimeout,
+		writeTimeout:      defaultWriteTimeout,
+		idleTimeout:       defaultIdleTimeout,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.pattern == "" {
+		cfg.pattern = defaultPattern
+	}
+	srv := &http.Server{
+		ReadHeaderTimeout: cfg.readHeaderTimeout,
+		ReadTimeout:       cfg.readTimeout,
+		WriteTimeout:      cfg.writeTimeout,
+		IdleTimeout:       cfg.idleTimeout,
+	}
+	if cfg.disableHTTP2 {
+		// TLSNextProto为非nil的空map时不会启用HTTP/2
+		srv.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler))
+	}
+	s := &Server{
+		addr:    addr,
+		cfg:     cfg,
+		srv:     srv,
+		mux:     http.NewServeMux(),
+		metrics: NewMetrics(),
+		stats:   make(map[string]StatsProvider),
+		health:  NewHealthChecker(0),
+		events:  channel.NewBroker[string](channel.PolicyDrop, eventsBuffer),
+	}
+	s.metrics.route = s.routePattern
+	s.mux.HandleFunc(cfg.pattern, helloServer)
+	s.mux.HandleFunc("/healthz", s.healthz)
+	s.mux.HandleFunc("/readyz", s.readyz)
+	s.mux.Handle("/metrics", s.metrics)
+	s.mux.HandleFunc("/echo", echoServer)
+	s.mux.HandleFunc("/version", versionServer)
+	s.mux.HandleFunc("/events", s.eventsServer)
+	s.mux.HandleFunc("/debug/stats", s.debugStats)
+	if cfg.staticDir != "" {
+		s.mux.Handle("/static/", http.StripPrefix("/static", StaticHandler(cfg.staticDir, cfg.staticMaxAge)))
+	}
+	if cfg.pprofToken != "" {
+		MountPprof(s.mux, cfg.pprofToken)
+	}
+	return s
+}
+
+// routePattern 请求在s.mux里匹配到的路由，没有匹配上时返回""
+func (s *Server) routePattern(req *http.Request) string {
+	_, pattern := s.mux.Handler(req)
+	return pattern
+}
+
+// Handle 注册路由，需要在Run之前调用
+func (s *Server) Handle(pattern string, h http.Handler) {
+	s.mux.Handle(pattern, h)
+}
+
+// Run 启动服务并阻塞，直到ctx取消、收到退出信号或者服务出错
+// 正常关闭时返回nil
+func (s *Server) Run(ctx context.Context) error {
+	if s.cfg.unixSocket != "" {
+		s.srv.Addr = s.cfg.unixSocket
+	} else {
+		addr, err := resolveAddr(s.addr)
+		if err != nil {
+			return err
+		}
+		s.srv.Addr = addr
+	}
+	var middlewares []func(http.Handler) http.Handler
+	if len(s.cfg.corsOrigins) > 0 {
+		middlewares = append(middlewares, CORSMiddleware(s.cfg.corsOrigins))
+	}
+	middlewares = append(middlewares, s.trackInflight, s.metrics.Middleware)
+	middlewares = append(middlewares, baseMiddlewares...)
+	// 放在RequestIDMiddleware后面，超限和慢请求的日志能带上request_id
+	if s.cfg.slowRequest > 0 {
+		middlewares = append(middlewares, SlowRequestMiddleware(s.cfg.slowRequest))
+	}
+	if s.cfg.maxBodyBytes > 0 {
+		middlewares = append(middlewares, MaxBodyMiddleware(s.cfg.maxBodyBytes))
+	}
+	if s.cfg.maxRespBytes > 0 {
+		middlewares = append(middlewares, ResponseLimiter(s.cfg.maxRespBytes))
+	}
+	s.srv.Handler = Chain(middlewares...)(s.mux)
+
+	var reloader *CertReloader
+	var err error
+	if s.cfg.certReload > 0 && s.cfg.certFile != "" && s.cfg.keyFile != "" {
+		reloader, err = NewCertReloader(s.cfg.certFile, s.cfg.keyFile)
+		if err != nil {
+			return err
+		}
+		if s.srv.TLSConfig == nil {
+			s.srv.TLSConfig = &tls.Config{}
+		}
+		s.srv.TLSConfig.GetCertificate = reloader.GetCertificate
+	}
+
+	// 先把socket监听起来，热重启时要把它交给子进程
+	var ln net.Listener
+	if s.cfg.unixSocket != "" {
+		ln, err = listenUnix(s.cfg.unixSocket, s.cfg.socketPerm)
+	} else {
+		ln, err = listen(s.srv.Addr)
+	}
+	if err != nil {
+		return err
+	}
+	// 热重启成功后socket文件交给了子进程，关闭时不能删
+	var restarted bool
+	if s.cfg.unixSocket != "" {
+		defer func() {
+			if !restarted {
+				os.Remove(s.cfg.unixSocket)
+			}
+		}()
+	}
+
+	r := &serverRunner{
+		ln: ln,
+		serve: func(ln net.Listener) error {
+			// 热重启要用原始的ln拿fd，限流只套在Serve用的listener上
+			if s.cfg.maxConns > 0 {
+				ln = LimitListener(ln, s.cfg.maxConns)
+			}
+			return serveListener(s.srv, ln, s.cfg.certFile, s.cfg.keyFile)
+		},
+		shutdown: s.gracefulShutdown,
+		onRestart: func() error {
+			if err := gracefulRestart(ln); err != nil {
+				return err
+			}
+			restarted = true
+			return nil
+		},
+		onShuttingDown: func() {
+			s.advancePhase(PhaseNotReady)
+		},
+	}
+	if reloader != nil {
+		r.background = append(r.background, func(ctx context.Context) error {
+			reloader.Watch(ctx, s.cfg.certReload)
+			return nil
+		})
+	}
+
+	var stop func()
+	r.stop, stop = notifySignals(s.cfg.signals...)
+	defer stop()
+	// 不支持热重启的平台上restartSignals为空，不注册
+	r.restart, stop = notifySignals(restartSignals...)
+	defer stop()
+	// 调整日志级别的信号，同样只在支持的平台上注册
+	if levelUpSignal != nil && levelDownSignal != nil {
+		r.levelUp, stop = notifySignals(levelUpSignal)
+		defer stop()
+		r.levelDown, stop = notifySignals(levelDownSignal)
+		defer stop()
+	}
+	return r.run(ctx)
+}
+
+// gracefulShutdown 按固定的顺序关闭：摘掉readiness、停止接收新连接、等请求处理完、执行OnShutdown回调
+// 每进入一个阶段都会调用WithPhaseHook设置的回调
+func (s *Server) gracefulShutdown() error {
+	// 通常信号goroutine已经推进到这里了，ctx被调用方取消时由这里推进
+	s.advancePhase(PhaseNotReady)
+
+	// 后面所有步骤共用一个grace时间
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.cfg.shutdownGrace)
+	defer cancel()
+
+	s.advancePhase(PhaseStopAccepting)
+	// 排空请求期间不再复用连接，客户端处理完当前请求就去连别的实例
+	s.DisableKeepAlives()
+	// /events的长连接不会自己结束，关掉broker让它们退出，否则排空请求要等到超时
+	if err := s.events.Close(shutdownCtx); err != nil {
+		logger.Warn("close event broker", slog.Any("error", err))
+	}
+	// Shutdown一开始就关闭监听socket，然后等连接都空闲下来
+	shutdownErr := make(chan error, 1)
+	go func() {
+		shutdownErr <- shutdown(shutdownCtx, s.srv)
+	}()
+
+	s.advancePhase(PhaseDraining)
+	if err := s.WaitForDrain(shutdownCtx); err != nil {
+		logger.Warn("drain in-flight requests", slog.Int64("inflight", s.Inflight()), slog.Any("error", err))
+	}
+	err := <-shutdownErr
+
+	// 服务已经停了，再执行回调关闭依赖的资源
+	s.advancePhase(PhaseHooks)
+	err = errors.Join(err, s.runShutdownHooks(shutdownCtx))
+
+	s.advancePhase(PhaseStopped)
+	return err
+}
+
+// RunFor 和Run一样，但最多运行d时间，到时间后优雅关闭
+// 超时触发的正常关闭返回nil，其他情况返回实际的错误
+func (s *Server) RunFor(ctx context.Context, d time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+	return s.Run(ctx)
+}
+
+// healthz 正常时返回200，开始关闭后返回503，让负载均衡尽快摘掉流量
+func (s *Server) healthz(w http.ResponseWriter, req *http.Request) {
+	if s.shuttingDown.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		io.WriteString(w, "shutting down")
+		return
+	}
+	io.WriteString(w, "ok")
+}
+
+// RegisterHealthCheck 注册/readyz要探测的依赖，check返回nil表示健康
+func (s *Server) RegisterHealthCheck(name string, check func(ctx context.Context) error) {
+	s.health.Register(name, check)
+}
+
+// readyz 探测所有注册的依赖，全部健康才返回200；/healthz不探测依赖
+// 开始关闭后不再探测，直接返回503
+func (s *Server) readyz(w http.ResponseWriter, req *http.Request) {
+	if s.shuttingDown.Load() {
+		writeJSONError(w, http.StatusServiceUnavailable, "shutting down")
+		return
+	}
+	s.health.ServeHTTP(w, req)
+}
+
+// StartHttpServer 启动http服务
+// addr为空时读取环境变量HTTP_ADDR，仍为空则使用:8080
+// pattern为空时hello挂载到/hello
+func StartHttpServer(src *http.Server, addr, pattern string) error {
+	addr, err := resolveAddr(addr)
+	if err != nil {
+		return err
+	}
+	if pattern == "" {
+		pattern = defaultPattern
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(pattern, helloServer)
+	src.Addr = addr
+	src.Handler = Chain(baseMiddlewares...)(mux)
+	fmt.Println("start", src.Addr)
+	return src.ListenAndServe()
+}
+
+// StartHttpsServer 用证书启动https服务，src需要已经设置好Addr和Handler
+// certFile或keyFile为空时退回普通http
+func StartHttpsServer(src *http.Server, certFile, keyFile string) error {
+	if certFile == "" || keyFile == "" {
+		fmt.Println("start", src.Addr)
+		return src.ListenAndServe()
+	}
+	fmt.Println("start tls", src.Addr)
+	return src.ListenAndServeTLS(certFile, keyFile)
+}
+
+// serveListener 在ln上提供服务，certFile或keyFile为空时是普通http
+// TLSConfig里设置了GetCertificate（证书热加载）时由它提供证书，不再读文件
+func serveListener(src *http.Server, ln net.Listener, certFile, keyFile string) error {
+	if src.TLSConfig != nil && src.TLSConfig.GetCertificate != nil {
+		fmt.Println("start tls", ln.Addr())
+		return src.ServeTLS(ln, "", "")
+	}
+	if certFile == "" || keyFile == "" {
+		fmt.Println("start", ln.Addr())
+		return src.Serve(ln)
+	}
+	fmt.Println("start tls", ln.Addr())
+	return src.ServeTLS(ln, certFile, keyFile)
+}
+
+// listen 优先使用热重启时从父进程继承的socket，没有再新建
+func listen(addr string) (net.Listener, error) {
+	ln, err := inheritedListener()
+	if err != nil {
+		return nil, fmt.Errorf("inherit listener: %w", err)
+	}
+	if ln != nil {
+		return ln, nil
+	}
+	return net.Listen("tcp", addr)
+}
+
+// baseMiddlewares 所有服务都会套上的中间件
+// recover放在logging里面，这样panic的请求也会以500记到日志里；
+// 请求ID放在最外层，logging和后面的handler都能拿到
+var baseMiddlewares = []func(http.Handler) http.Handler{
+	RequestIDMiddleware,
+	LoggingMiddleware,
+	RecoverMiddleware,
+}
+
+// resolveAddr addr为空时读取环境变量HTTP_ADDR，仍为空则使用:8080，并校验格式
+func resolveAddr(addr string) (string, error) {
+	if addr == "" {
+		addr = os.Getenv("HTTP_ADDR")
+	}
+	if addr == "" {
+		addr = defaultAddr
+	}
+	// 地址格式不对直接返回，不要等到Listen才报错
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return "", fmt.Errorf("invalid listen address %q: %w", addr, err)
+	}
+	return addr, nil
+}
+
+// ShutdownServer 等待ctx结束后优雅关闭srv
+// ctx此时已经取消，不能再传给Shutdown（会立即返回，来不及处理完已有连接），
+// 所以重新创建一个带超时的context，超过grace还没关完就直接Close
+func ShutdownServer(ctx context.Context, srv *http.Server, grace time.Duration) error {
+	<-ctx.Done()
+	if grace <= 0 {
+		grace = defaultShutdownGrace
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+	return shutdown(shutdownCtx, srv)
+}
+
+// shutdown 在ctx结束前优雅关闭srv，超时则直接Close
+func shutdown(ctx context.Context, srv *http.Server) error {
+	fmt.Println("stop")
+	if err := srv.Shutdown(ctx); err != nil {
+		srv.Close()
+		return err
+	}
+	return nil
+}
+
+func helloServer(w http.ResponseWriter, req *http.Request) {
+	id, _ := RequestIDFromContext(req.Context())
+	logger.Debug("hello", slog.String("request_id", id))
+	io.WriteString(w, "hello Go")
+}
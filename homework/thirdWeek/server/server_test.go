@@ -0,0 +1,462 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testClient 测试里用的是自签名证书，不校验；https时尽量用HTTP/2
+var testClient = &http.Client{
+	Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+		ForceAttemptHTTP2: true,
+	},
+}
+
+// freeAddr 找一个当前空闲的本地端口
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	return addr
+}
+
+// get 请求url直到服务起来，返回状态码和响应体
+func get(t *testing.T, url string) (int, string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		resp, err := testClient.Get(url)
+		if err == nil {
+			b, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			return resp.StatusCode, string(b)
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("GET %s: %v", url, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// runServer 在空闲端口上运行NewServer创建的服务，等它能处理请求后返回地址和Run的结果
+func runServer(t *testing.T, ctx context.Context, opts ...Option) (string, <-chan error) {
+	t.Helper()
+	return startServer(t, ctx, NewServer(freeAddr(t), opts...))
+}
+
+// startServer 运行s，s需要用freeAddr的地址创建
+func startServer(t *testing.T, ctx context.Context, s *Server) (string, <-chan error) {
+	t.Helper()
+	errc := make(chan error, 1)
+	go func() {
+		errc <- s.Run(ctx)
+	}()
+	base := "http://" + s.addr
+	if s.cfg.certFile != "" {
+		base = "https://" + s.addr
+	}
+	get(t, base+"/healthz")
+	return base, errc
+}
+
+// waitRun 等待Run返回，超时算失败
+func waitRun(t *testing.T, errc <-chan error) error {
+	t.Helper()
+	select {
+	case err := <-errc:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return")
+		return nil
+	}
+}
+
+// writeCert 在dir下生成自签名的证书和私钥，返回两个文件的路径
+func writeCert(t *testing.T, dir, cn string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestResolveAddr(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string
+		addr    string
+		want    string
+		wantErr bool
+	}{
+		{name: "default", want: ":8080"},
+		{name: "env", env: "127.0.0.1:9090", want: "127.0.0.1:9090"},
+		{name: "param over env", env: ":9090", addr: ":7070", want: ":7070"},
+		{name: "missing port", addr: "localhost", wantErr: true},
+		{name: "malformed env", env: "::1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HTTP_ADDR", tt.env)
+			got, err := resolveAddr(tt.addr)
+			if tt.wantErr {
+				var addrErr *net.AddrError
+				if !errors.As(err, &addrErr) {
+					t.Fatalf("resolveAddr(%q) error = %v, want wrapped *net.AddrError", tt.addr, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("resolveAddr(%q) = %q, %v, want %q", tt.addr, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestStartHttpServerInvalidAddr(t *testing.T) {
+	if err := StartHttpServer(&http.Server{}, "localhost", ""); err == nil {
+		t.Fatal("StartHttpServer with malformed address returned nil")
+	}
+}
+
+func TestStartHttpServerTwoInstances(t *testing.T) {
+	// 两个实例用不同的端口和路由同时运行
+	for _, pattern := range []string{"/hello", "/v2/hello"} {
+		srv := &http.Server{}
+		addr := freeAddr(t)
+		go StartHttpServer(srv, addr, pattern)
+		t.Cleanup(func() { srv.Close() })
+
+		code, body := get(t, "http://"+addr+pattern)
+		if code != http.StatusOK || body != "hello Go" {
+			t.Fatalf("GET %s = %d %q", pattern, code, body)
+		}
+	}
+}
+
+// serveBlocking 启动一个handler会阻塞到release关闭的服务，entered在handler开始执行时收到值
+func serveBlocking(t *testing.T, release <-chan struct{}) (srv *http.Server, url string, entered <-chan struct{}) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := make(chan struct{}, 1)
+	srv = &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		in <- struct{}{}
+		<-release
+		io.WriteString(w, "done")
+	})}
+	go srv.Serve(ln)
+	return srv, "http://" + ln.Addr().String(), in
+}
+
+func TestShutdownServerDrainsInflight(t *testing.T) {
+	release := make(chan struct{})
+	srv, url, entered := serveBlocking(t, release)
+
+	result := make(chan int, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			result <- 0
+			return
+		}
+		resp.Body.Close()
+		result <- resp.StatusCode
+	}()
+	<-entered
+
+	// ctx已经取消，ShutdownServer仍然要等请求处理完
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	errc := make(chan error, 1)
+	go func() { errc <- ShutdownServer(ctx, srv, time.Second) }()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	if err := <-errc; err != nil {
+		t.Fatalf("ShutdownServer = %v, want nil", err)
+	}
+	if code := <-result; code != http.StatusOK {
+		t.Fatalf("in-flight request got %d, want 200", code)
+	}
+}
+
+func TestShutdownServerGraceExceeded(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	srv, url, entered := serveBlocking(t, release)
+
+	clientErr := make(chan error, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err == nil {
+			resp.Body.Close()
+		}
+		clientErr <- err
+	}()
+	<-entered
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	err := ShutdownServer(ctx, srv, 50*time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("ShutdownServer = %v, want DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("ShutdownServer took %v with a 50ms grace", elapsed)
+	}
+	// 超时后Close强制断开了连接
+	if err := <-clientErr; err == nil {
+		t.Fatal("request survived a forced close")
+	}
+}
+
+func TestServerRunStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	base, errc := runServer(t, ctx)
+
+	code, body := get(t, base+"/hello")
+	if code != http.StatusOK || body != "hello Go" {
+		t.Fatalf("GET /hello = %d %q", code, body)
+	}
+	cancel()
+	if err := waitRun(t, errc); err != nil {
+		t.Fatalf("Run = %v, want nil", err)
+	}
+}
+
+func TestServerHandleSeparateMux(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	text := func(s string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { io.WriteString(w, s) })
+	}
+	s1 := NewServer(freeAddr(t))
+	s1.Handle("/a", text("one"))
+	s1.Handle("/b", text("b"))
+	s2 := NewServer(freeAddr(t))
+	s2.Handle("/a", text("two"))
+	base1, errc1 := startServer(t, ctx, s1)
+	base2, errc2 := startServer(t, ctx, s2)
+
+	tests := []struct {
+		url  string
+		code int
+		body string
+	}{
+		{base1 + "/a", http.StatusOK, "one"},
+		{base1 + "/b", http.StatusOK, "b"},
+		{base2 + "/a", http.StatusOK, "two"},
+		{base2 + "/b", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		code, body := get(t, tt.url)
+		if code != tt.code || (tt.body != "" && body != tt.body) {
+			t.Fatalf("GET %s = %d %q, want %d %q", tt.url, code, body, tt.code, tt.body)
+		}
+	}
+	// 路由都注册在各自的mux上，全局的DefaultServeMux没有被动过
+	if _, pattern := http.DefaultServeMux.Handler(httptest.NewRequest(http.MethodGet, "/hello", nil)); pattern != "" {
+		t.Fatalf("DefaultServeMux has pattern %q for /hello", pattern)
+	}
+
+	cancel()
+	for _, errc := range []<-chan error{errc1, errc2} {
+		if err := waitRun(t, errc); err != nil {
+			t.Fatalf("Run = %v, want nil", err)
+		}
+	}
+}
+
+func TestServerRunFor(t *testing.T) {
+	s := NewServer(freeAddr(t))
+	start := time.Now()
+	if err := s.RunFor(context.Background(), 50*time.Millisecond); err != nil {
+		t.Fatalf("RunFor = %v, want nil", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond || elapsed > 5*time.Second {
+		t.Fatalf("RunFor returned after %v, want about 50ms", elapsed)
+	}
+	// 关闭流程走完了
+	if p := s.Phase(); p != PhaseStopped {
+		t.Fatalf("phase = %v, want %v", p, PhaseStopped)
+	}
+
+	// 监听失败这样的真实错误照常返回
+	if err := NewServer("127.0.0.1:-1").RunFor(context.Background(), time.Second); err == nil {
+		t.Fatal("RunFor with an invalid address returned nil")
+	}
+}
+
+func TestHealthzReflectsShutdown(t *testing.T) {
+	// 进入PhaseNotReady时（信号goroutine里）立刻查一次/healthz
+	var s *Server
+	during := make(chan int, 1)
+	s = NewServer(freeAddr(t), WithPhaseHook(func(p ShutdownPhase) {
+		if p == PhaseNotReady {
+			rec := httptest.NewRecorder()
+			s.mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+			during <- rec.Code
+		}
+	}))
+	ctx, cancel := context.WithCancel(context.Background())
+	base, errc := startServer(t, ctx, s)
+
+	if code, _ := get(t, base+"/healthz"); code != http.StatusOK {
+		t.Fatalf("GET /healthz before shutdown = %d, want 200", code)
+	}
+	cancel()
+	if err := waitRun(t, errc); err != nil {
+		t.Fatalf("Run = %v, want nil", err)
+	}
+	if code := <-during; code != http.StatusServiceUnavailable {
+		t.Fatalf("GET /healthz during shutdown = %d, want 503", code)
+	}
+}
+
+func TestStartHttpsServer(t *testing.T) {
+	certFile, keyFile := writeCert(t, t.TempDir(), "localhost")
+	tests := []struct {
+		name     string
+		certFile string
+		keyFile  string
+		scheme   string
+	}{
+		{name: "tls", certFile: certFile, keyFile: keyFile, scheme: "https"},
+		{name: "no cert falls back to http", scheme: "http"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/hello", helloServer)
+			srv := &http.Server{Addr: freeAddr(t), Handler: mux}
+			go StartHttpsServer(srv, tt.certFile, tt.keyFile)
+			defer srv.Close()
+
+			code, body := get(t, tt.scheme+"://"+srv.Addr+"/hello")
+			if code != http.StatusOK || body != "hello Go" {
+				t.Fatalf("GET /hello = %d %q", code, body)
+			}
+		})
+	}
+}
+
+func TestServerHTTP2(t *testing.T) {
+	certFile, keyFile := writeCert(t, t.TempDir(), "localhost")
+	tests := []struct {
+		name  string
+		opts  []Option
+		proto int
+	}{
+		{name: "default", opts: []Option{WithTLS(certFile, keyFile)}, proto: 2},
+		{name: "disabled", opts: []Option{WithTLS(certFile, keyFile), WithoutHTTP2()}, proto: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			base, errc := runServer(t, ctx, tt.opts...)
+
+			resp, err := testClient.Get(base + "/hello")
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.ProtoMajor != tt.proto {
+				t.Fatalf("ProtoMajor = %d, want %d", resp.ProtoMajor, tt.proto)
+			}
+			cancel()
+			if err := waitRun(t, errc); err != nil {
+				t.Fatalf("Run = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestServerReadHeaderTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	base, errc := runServer(t, ctx, WithReadHeaderTimeout(100*time.Millisecond))
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(base, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	// 只写一半请求头，模拟慢客户端
+	if _, err := io.WriteString(conn, "GET /hello HTTP/1.1\r\nHost: localhost\r\n"); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, err := io.ReadAll(conn); err != nil {
+		t.Fatalf("connection was not closed by the server: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("connection closed after %v, before ReadHeaderTimeout", elapsed)
+	}
+
+	cancel()
+	if err := waitRun(t, errc); err != nil {
+		t.Fatalf("Run = %v, want nil", err)
+	}
+}
+
+func TestNewServerTimeouts(t *testing.T) {
+	s := NewServer("", WithReadTimeout(time.Second), WithWriteTimeout(2*time.Second), WithIdleTimeout(3*time.Second))
+	if s.srv.ReadHeaderTimeout != defaultReadHeaderTimeout {
+		t.Fatalf("ReadHeaderTimeout = %v, want default %v", s.srv.ReadHeaderTimeout, defaultReadHeaderTimeout)
+	}
+	if s.srv.ReadTimeout != time.Second || s.srv.WriteTimeout != 2*time.Second || s.srv.IdleTimeout != 3*time.Second {
+		t.Fatalf("timeouts = %v/%v/%v", s.srv.ReadTimeout, s.srv.WriteTimeout, s.srv.IdleTimeout)
+	}
+}
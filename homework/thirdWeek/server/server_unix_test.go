@@ -0,0 +1,120 @@
+//go:build unix
+
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestServerRunStopsOnSIGTERM(t *testing.T) {
+	base, errc := runServer(t, context.Background())
+
+	code, body := get(t, base+"/hello")
+	if code != http.StatusOK || body != "hello Go" {
+		t.Fatalf("GET /hello = %d %q", code, body)
+	}
+	// Run已经注册了SIGTERM，发给自己不会杀掉测试进程
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+	if err := waitRun(t, errc); err != nil {
+		t.Fatalf("Run = %v, want nil", err)
+	}
+}
+
+func TestServerIgnoresUnregisteredSignal(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	base, errc := runServer(t, ctx)
+
+	// SIGHUP在linux上用于热重启，这里用默认忽略的SIGWINCH代表无关信号
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGWINCH); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-errc:
+		t.Fatalf("Run returned %v after an unregistered signal", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if code, _ := get(t, base+"/healthz"); code != http.StatusOK {
+		t.Fatalf("GET /healthz = %d, want 200", code)
+	}
+
+	cancel()
+	if err := waitRun(t, errc); err != nil {
+		t.Fatalf("Run = %v, want nil", err)
+	}
+}
+
+func TestServerWithSignals(t *testing.T) {
+	_, errc := runServer(t, context.Background(), WithSignals(syscall.SIGWINCH))
+
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGWINCH); err != nil {
+		t.Fatal(err)
+	}
+	if err := waitRun(t, errc); err != nil {
+		t.Fatalf("Run = %v, want nil", err)
+	}
+}
+
+func TestServerLogLevelSignals(t *testing.T) {
+	// 和默认logger一样使用LogLevel()，信号切换级别才能生效
+	buf := new(logBuffer)
+	old := logger
+	SetLogger(slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: LogLevel()})))
+	t.Cleanup(func() {
+		SetLogger(old)
+		LogLevel().Set(slog.LevelInfo)
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, errc := runServer(t, ctx)
+
+	debugLogged := func(msg string) bool {
+		logger.Debug(msg)
+		for _, e := range buf.entries(t) {
+			if e["msg"] == msg {
+				return true
+			}
+		}
+		return false
+	}
+	waitLevel := func(want slog.Level) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for LogLevel().Level() != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("log level = %v, want %v", LogLevel().Level(), want)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	if debugLogged("before") {
+		t.Fatal("debug log emitted at the default Info level")
+	}
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+	waitLevel(slog.LevelDebug)
+	if !debugLogged("after SIGUSR1") {
+		t.Fatal("debug log not emitted after SIGUSR1")
+	}
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR2); err != nil {
+		t.Fatal(err)
+	}
+	waitLevel(slog.LevelInfo)
+	if debugLogged("after SIGUSR2") {
+		t.Fatal("debug log emitted after SIGUSR2")
+	}
+
+	cancel()
+	if err := waitRun(t, errc); err != nil {
+		t.Fatalf("Run = %v, want nil", err)
+	}
+}
@@ -0,0 +1,30 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// SlowRequestMiddleware handler执行超过threshold时打一条Warn日志，请求照常处理完
+// 只做观察，需要强制超时用TimeoutMiddleware；放在RequestIDMiddleware后面日志里才有request_id
+func SlowRequestMiddleware(threshold time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			start := time.Now()
+			next.ServeHTTP(w, req)
+			elapsed := time.Since(start)
+			if elapsed < threshold {
+				return
+			}
+			id, _ := RequestIDFromContext(req.Context())
+			logger.Warn("slow request",
+				slog.String("request_id", id),
+				slog.String("method", req.Method),
+				slog.String("path", req.URL.Path),
+				slog.Duration("duration", elapsed),
+				slog.Duration("threshold", threshold),
+			)
+		})
+	}
+}
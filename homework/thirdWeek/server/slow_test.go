@@ -0,0 +1,61 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSlowRequestMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		sleep    time.Duration
+		wantSlow bool
+	}{
+		{name: "slow", sleep: 30 * time.Millisecond, wantSlow: true},
+		{name: "fast", wantSlow: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureLogs(t)
+			h := Chain(RequestIDMiddleware, SlowRequestMiddleware(20*time.Millisecond))(
+				http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					time.Sleep(tt.sleep)
+					w.Write([]byte("done"))
+				}))
+			req := httptest.NewRequest(http.MethodPost, "/report", nil)
+			req.Header.Set(RequestIDHeader, "req-1")
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			// 只记日志，不影响响应
+			if rec.Code != http.StatusOK || rec.Body.String() != "done" {
+				t.Fatalf("response = %d %q, want 200 done", rec.Code, rec.Body)
+			}
+			var slow []map[string]any
+			for _, e := range logs.entries(t) {
+				if e["msg"] == "slow request" {
+					slow = append(slow, e)
+				}
+			}
+			if !tt.wantSlow {
+				if len(slow) != 0 {
+					t.Fatalf("fast request logged as slow: %v", slow)
+				}
+				return
+			}
+			if len(slow) != 1 {
+				t.Fatalf("got %d slow request logs, want 1", len(slow))
+			}
+			e := slow[0]
+			if e["level"] != "WARN" || e["request_id"] != "req-1" || e["method"] != http.MethodPost || e["path"] != "/report" {
+				t.Fatalf("slow request log = %v", e)
+			}
+			// JSONHandler把Duration写成纳秒数
+			if d, _ := e["duration"].(float64); time.Duration(d) < tt.sleep {
+				t.Fatalf("duration = %v, want >= %v", time.Duration(d), tt.sleep)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// StaticHandler 提供root目录下的静态文件，设置Cache-Control: max-age和弱ETag（由修改时间和大小生成）
+// If-None-Match匹配时返回304；路径里带..的请求直接返回400，不会读到root以外的文件
+// 挂在子路径下时需要配合http.StripPrefix使用
+func StaticHandler(root string, maxAge time.Duration) http.Handler {
+	dir := http.Dir(root)
+	files := http.FileServer(dir)
+	cacheControl := fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds()))
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if hasDotDot(req.URL.Path) {
+			writeJSONError(w, http.StatusBadRequest, "invalid path")
+			return
+		}
+		// 目录和不存在的文件交给FileServer处理（目录列表、301、404）
+		if etag, ok := fileETag(dir, path.Clean("/"+req.URL.Path)); ok {
+			w.Header().Set("Cache-Control", cacheControl)
+			w.Header().Set("ETag", etag)
+			if etagMatch(req.Header.Get("If-None-Match"), etag) {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+		files.ServeHTTP(w, req)
+	})
+}
+
+// fileETag name是普通文件时返回由修改时间和大小生成的弱ETag
+func fileETag(dir http.Dir, name string) (string, bool) {
+	f, err := dir.Open(name)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil || fi.IsDir() {
+		return "", false
+	}
+	return fmt.Sprintf(`W/"%x-%x"`, fi.ModTime().UnixNano(), fi.Size()), true
+}
+
+// etagMatch If-None-Match里有任意一个和etag弱匹配（忽略W/前缀），或者是*
+func etagMatch(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	want := strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
+
+// hasDotDot 路径中是否有..这一段
+func hasDotDot(p string) bool {
+	if !strings.Contains(p, "..") {
+		return false
+	}
+	for _, seg := range strings.FieldsFunc(p, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if seg == ".." {
+			return true
+		}
+	}
+	return false
+}
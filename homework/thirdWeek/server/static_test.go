@@ -0,0 +1,134 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// staticDir 建一个临时目录，里面有index.txt和sub/a.txt，目录外还有一个secret.txt
+func staticDir(t *testing.T) string {
+	t.Helper()
+	parent := t.TempDir()
+	root := filepath.Join(parent, "public")
+	if err := os.MkdirAll(filepath.Join(root, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		filepath.Join(root, "index.txt"):    "hello static",
+		filepath.Join(root, "sub", "a.txt"): "a",
+		filepath.Join(parent, "secret.txt"): "secret",
+	} {
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func serveStatic(h http.Handler, path, ifNoneMatch string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if ifNoneMatch != "" {
+		req.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestStaticHandler(t *testing.T) {
+	h := StaticHandler(staticDir(t), time.Hour)
+
+	rec := serveStatic(h, "/index.txt", "")
+	if rec.Code != http.StatusOK || rec.Body.String() != "hello static" {
+		t.Fatalf("GET /index.txt = %d %q", rec.Code, rec.Body)
+	}
+	if cc := rec.Header().Get("Cache-Control"); cc != "public, max-age=3600" {
+		t.Fatalf("Cache-Control = %q", cc)
+	}
+	etag := rec.Header().Get("ETag")
+	if len(etag) < 4 || etag[:3] != `W/"` {
+		t.Fatalf("ETag = %q, want a weak ETag", etag)
+	}
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		wantCode    int
+	}{
+		{name: "match", ifNoneMatch: etag, wantCode: http.StatusNotModified},
+		{name: "strong form matches weakly", ifNoneMatch: etag[2:], wantCode: http.StatusNotModified},
+		{name: "one of a list", ifNoneMatch: `"other", ` + etag, wantCode: http.StatusNotModified},
+		{name: "star", ifNoneMatch: "*", wantCode: http.StatusNotModified},
+		{name: "stale", ifNoneMatch: `W/"0-0"`, wantCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveStatic(h, "/index.txt", tt.ifNoneMatch)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if tt.wantCode == http.StatusNotModified && rec.Body.Len() != 0 {
+				t.Fatalf("304 with body %q", rec.Body)
+			}
+		})
+	}
+}
+
+func TestStaticHandlerETagChanges(t *testing.T) {
+	root := staticDir(t)
+	h := StaticHandler(root, time.Minute)
+	before := serveStatic(h, "/index.txt", "").Header().Get("ETag")
+	// 内容和修改时间变了，旧的ETag不能再返回304
+	name := filepath.Join(root, "index.txt")
+	if err := os.WriteFile(name, []byte("changed content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(name, later, later); err != nil {
+		t.Fatal(err)
+	}
+	rec := serveStatic(h, "/index.txt", before)
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") == before {
+		t.Fatalf("modified file: status %d, ETag %q (was %q)", rec.Code, rec.Header().Get("ETag"), before)
+	}
+}
+
+func TestStaticHandlerPaths(t *testing.T) {
+	h := StaticHandler(staticDir(t), time.Hour)
+	tests := []struct {
+		name      string
+		path      string
+		wantCode  int
+		wantCache bool
+	}{
+		{name: "nested file", path: "/sub/a.txt", wantCode: http.StatusOK, wantCache: true},
+		{name: "traversal", path: "/../secret.txt", wantCode: http.StatusBadRequest},
+		{name: "nested traversal", path: "/sub/../../secret.txt", wantCode: http.StatusBadRequest},
+		{name: "missing", path: "/nope.txt", wantCode: http.StatusNotFound},
+		// 目录不设置缓存头，交给FileServer列目录
+		{name: "directory", path: "/sub/", wantCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveStatic(h, tt.path, "")
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if got := rec.Header().Get("ETag") != ""; got != tt.wantCache {
+				t.Fatalf("ETag set = %v, want %v", got, tt.wantCache)
+			}
+		})
+	}
+}
+
+func TestServerWithStatic(t *testing.T) {
+	s := NewServer("", WithStatic(staticDir(t), time.Hour))
+	rec := serveStatic(s.mux, "/static/index.txt", "")
+	if rec.Code != http.StatusOK || rec.Body.String() != "hello static" {
+		t.Fatalf("GET /static/index.txt = %d %q", rec.Code, rec.Body)
+	}
+}
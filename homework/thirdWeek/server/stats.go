@@ -0,0 +1,47 @@
+package server
+
+import (
+	"net/http"
+)
+
+// StatsProvider 能汇报内部计数的子系统，注册后会出现在/debug/stats里
+type StatsProvider interface {
+	Stats() map[string]any
+}
+
+// StatsFunc 让普通函数实现StatsProvider，例如汇报一个RWMutex当前的读者数：
+//
+//	s.RegisterStats("cache_lock", server.StatsFunc(func() map[string]any {
+//		return map[string]any{"readers": mu.ReaderCount()}
+//	}))
+type StatsFunc func() map[string]any
+
+// Stats 调用f本身
+func (f StatsFunc) Stats() map[string]any {
+	return f()
+}
+
+// RegisterStats 以name注册一个StatsProvider，同名的会被覆盖
+func (s *Server) RegisterStats(name string, p StatsProvider) {
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+	s.stats[name] = p
+}
+
+// debugStats 汇总所有注册的StatsProvider，以name为key返回JSON
+// 自带一个"server"，里面是正在处理的请求数
+func (s *Server) debugStats(w http.ResponseWriter, req *http.Request) {
+	s.statsMu.Lock()
+	providers := make(map[string]StatsProvider, len(s.stats))
+	for name, p := range s.stats {
+		providers[name] = p
+	}
+	s.statsMu.Unlock()
+
+	out := make(map[string]any, len(providers)+1)
+	out["server"] = map[string]any{"inflight": s.Inflight()}
+	for name, p := range providers {
+		out[name] = p.Stats()
+	}
+	writeJSON(w, http.StatusOK, out)
+}
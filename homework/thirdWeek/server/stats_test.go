@@ -0,0 +1,38 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDebugStats(t *testing.T) {
+	s := NewServer(freeAddr(t))
+	s.RegisterStats("fake", StatsFunc(func() map[string]any {
+		return map[string]any{"queue_depth": 3, "name": "pool"}
+	}))
+	// 同名的注册会覆盖之前的
+	s.RegisterStats("cache_lock", StatsFunc(func() map[string]any { return map[string]any{"readers": 0} }))
+	s.RegisterStats("cache_lock", StatsFunc(func() map[string]any { return map[string]any{"readers": 1} }))
+
+	rec := httptest.NewRecorder()
+	s.mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/stats", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /debug/stats = %d", rec.Code)
+	}
+	var got map[string]map[string]any
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	// JSON里的数字解出来是float64
+	if got["fake"]["queue_depth"] != 3.0 || got["fake"]["name"] != "pool" {
+		t.Fatalf("fake stats = %v", got["fake"])
+	}
+	if got["cache_lock"]["readers"] != 1.0 {
+		t.Fatalf("cache_lock stats = %v", got["cache_lock"])
+	}
+	if _, ok := got["server"]["inflight"]; !ok {
+		t.Fatalf("server stats = %v, want inflight", got["server"])
+	}
+}
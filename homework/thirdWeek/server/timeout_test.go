@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeoutMiddlewareFast(t *testing.T) {
+	h := TimeoutMiddleware(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("X-Handler", "fast")
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, "done")
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusCreated || rec.Body.String() != "done" || rec.Header().Get("X-Handler") != "fast" {
+		t.Fatalf("response = %d %q %v", rec.Code, rec.Body.String(), rec.Header())
+	}
+}
+
+func TestTimeoutMiddlewareSlow(t *testing.T) {
+	type outcome struct {
+		ctxErr   error
+		writeErr error
+	}
+	res := make(chan outcome, 1)
+	served := make(chan struct{})
+	h := TimeoutMiddleware(20 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		// 已经开始写了，超时后这部分也不能发出去
+		io.WriteString(w, "partial")
+		<-req.Context().Done()
+		// 等503发出去之后再写
+		<-served
+		_, err := io.WriteString(w, "late")
+		res <- outcome{req.Context().Err(), err}
+	}))
+	rec := httptest.NewRecorder()
+	start := time.Now()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	close(served)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("ServeHTTP took %v with a 20ms timeout", elapsed)
+	}
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", rec.Code)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, `"error"`) || strings.Contains(body, "partial") {
+		t.Fatalf("body = %q, want only the JSON error", body)
+	}
+
+	// handler的ctx被取消，之后的写入失败
+	select {
+	case o := <-res:
+		if !errors.Is(o.ctxErr, context.DeadlineExceeded) {
+			t.Fatalf("handler ctx error = %v, want DeadlineExceeded", o.ctxErr)
+		}
+		if !errors.Is(o.writeErr, http.ErrHandlerTimeout) {
+			t.Fatalf("late write error = %v, want ErrHandlerTimeout", o.writeErr)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handler ctx not canceled")
+	}
+}
+
+func TestTimeoutMiddlewarePanic(t *testing.T) {
+	h := TimeoutMiddleware(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		panic("boom")
+	}))
+	defer func() {
+		if p := recover(); p != "boom" {
+			t.Fatalf("recovered %v, want boom", p)
+		}
+	}()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+)
+
+// unix socket文件默认的权限，同组的进程（比如sidecar）可以连接
+const defaultSocketPerm os.FileMode = 0o660
+
+// listenUnix 在path上监听unix socket，热重启时优先使用继承来的socket
+// 启动时删掉上次异常退出留下的socket文件；Close时不自动删除文件，由Run在关闭后删除
+func listenUnix(path string, perm os.FileMode) (net.Listener, error) {
+	ln, err := inheritedListener()
+	if err != nil {
+		return nil, fmt.Errorf("inherit listener: %w", err)
+	}
+	if ln != nil {
+		return ln, nil
+	}
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+	ln, err = net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	// 热重启后子进程还在用这个文件，父进程关闭listener时不能删
+	ln.(*net.UnixListener).SetUnlinkOnClose(false)
+	if err := os.Chmod(path, perm); err != nil {
+		ln.Close()
+		os.Remove(path)
+		return nil, fmt.Errorf("chmod unix socket: %w", err)
+	}
+	return ln, nil
+}
+
+// removeStaleSocket path上是没有进程在用的socket文件时删掉它
+// 不是socket或者还能连上时返回错误，避免误删别的文件或者抢别的进程的socket
+func removeStaleSocket(path string) error {
+	fi, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if fi.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a unix socket", path)
+	}
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return fmt.Errorf("unix socket %s is in use", path)
+	}
+	return os.Remove(path)
+}
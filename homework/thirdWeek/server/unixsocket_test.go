@@ -0,0 +1,117 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// socketPath unix socket路径有长度限制（Linux上108字节），t.TempDir()可能太长
+func socketPath(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "srv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, "hello.sock")
+}
+
+// unixClient 所有请求都拨到path上的unix socket，URL里的host会被忽略
+func unixClient(path string) *http.Client {
+	return &http.Client{
+		Timeout: time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", path)
+			},
+		},
+	}
+}
+
+func TestServerUnixSocket(t *testing.T) {
+	path := socketPath(t)
+	// 上次异常退出留下的socket文件，启动时要删掉
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := NewServer("", WithUnixSocket(path), WithUnixSocketPerm(0o600))
+	errc := make(chan error, 1)
+	go func() { errc <- s.Run(ctx) }()
+
+	client := unixClient(path)
+	var body string
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := client.Get("http://unix/hello")
+		if err == nil {
+			b, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			body = string(b)
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("GET over unix socket: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if body != "hello Go" {
+		t.Fatalf("body = %q, want %q", body, "hello Go")
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := fi.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("socket perm = %o, want 600", perm)
+	}
+
+	cancel()
+	if err := waitRun(t, errc); err != nil {
+		t.Fatalf("Run = %v, want nil", err)
+	}
+	// 正常关闭后删掉socket文件
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		t.Fatalf("socket file left after shutdown: %v", err)
+	}
+}
+
+func TestRemoveStaleSocket(t *testing.T) {
+	// 不是socket的文件不能删
+	path := socketPath(t)
+	if err := os.WriteFile(path, []byte("data"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := removeStaleSocket(path); err == nil {
+		t.Fatal("removeStaleSocket removed a regular file")
+	}
+
+	// 还有进程在监听的socket也不能删
+	path = socketPath(t)
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	if err := removeStaleSocket(path); err == nil {
+		t.Fatal("removeStaleSocket removed a socket in use")
+	}
+
+	// 不存在时什么都不做
+	if err := removeStaleSocket(filepath.Join(filepath.Dir(path), "missing.sock")); err != nil {
+		t.Fatalf("removeStaleSocket on missing file = %v", err)
+	}
+}
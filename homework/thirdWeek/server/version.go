@@ -0,0 +1,60 @@
+package server
+
+import (
+	"net/http"
+	"runtime"
+	"runtime/debug"
+)
+
+// 构建时通过 -ldflags 注入，例如：
+//
+//	go build -ldflags "-X gostudy/homework/thirdWeek/server.Version=v1.0.0 \
+//	  -X gostudy/homework/thirdWeek/server.Commit=$(git rev-parse HEAD) \
+//	  -X gostudy/homework/thirdWeek/server.BuildTime=$(date -u +%FT%TZ)"
+var Version, Commit, BuildTime string
+
+// readBuildInfo 测试里替换掉，模拟没有ldflags时从构建信息里取值
+var readBuildInfo = debug.ReadBuildInfo
+
+// versionInfo /version 返回的JSON
+type versionInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+}
+
+// buildVersion 优先用 ldflags 注入的值，没有注入的字段从 debug.ReadBuildInfo 里补
+func buildVersion() versionInfo {
+	v := versionInfo{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+	info, ok := readBuildInfo()
+	if !ok {
+		return v
+	}
+	if v.Version == "" {
+		v.Version = info.Main.Version
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			if v.Commit == "" {
+				v.Commit = s.Value
+			}
+		case "vcs.time":
+			if v.BuildTime == "" {
+				v.BuildTime = s.Value
+			}
+		}
+	}
+	return v
+}
+
+// versionServer 返回版本、提交、构建时间和Go版本
+func versionServer(w http.ResponseWriter, req *http.Request) {
+	writeJSON(w, http.StatusOK, buildVersion())
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"runtime/debug"
+	"testing"
+)
+
+// setVersion 测试期间替换ldflags变量和构建信息
+func setVersion(t *testing.T, version, commit, buildTime string, info *debug.BuildInfo) {
+	t.Helper()
+	oldVersion, oldCommit, oldBuildTime, oldRead := Version, Commit, BuildTime, readBuildInfo
+	Version, Commit, BuildTime = version, commit, buildTime
+	readBuildInfo = func() (*debug.BuildInfo, bool) { return info, info != nil }
+	t.Cleanup(func() {
+		Version, Commit, BuildTime, readBuildInfo = oldVersion, oldCommit, oldBuildTime, oldRead
+	})
+}
+
+func getVersion(t *testing.T) versionInfo {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	versionServer(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /version = %d", rec.Code)
+	}
+	var v versionInfo
+	if err := json.NewDecoder(rec.Body).Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestVersion(t *testing.T) {
+	buildInfo := &debug.BuildInfo{
+		Main: debug.Module{Version: "v0.0.0-build"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "buildinfo-rev"},
+			{Key: "vcs.time", Value: "2020-01-01T00:00:00Z"},
+		},
+	}
+	tests := []struct {
+		name                       string
+		version, commit, buildTime string
+		info                       *debug.BuildInfo
+		want                       versionInfo
+	}{
+		{
+			name:    "ldflags",
+			version: "v1.2.3", commit: "abc123", buildTime: "2024-05-01T10:00:00Z",
+			info: buildInfo,
+			want: versionInfo{Version: "v1.2.3", Commit: "abc123", BuildTime: "2024-05-01T10:00:00Z"},
+		},
+		{
+			name: "build info fallback",
+			info: buildInfo,
+			want: versionInfo{Version: "v0.0.0-build", Commit: "buildinfo-rev", BuildTime: "2020-01-01T00:00:00Z"},
+		},
+		{
+			name:    "partial ldflags",
+			version: "v1.2.3",
+			info:    buildInfo,
+			want:    versionInfo{Version: "v1.2.3", Commit: "buildinfo-rev", BuildTime: "2020-01-01T00:00:00Z"},
+		},
+		{name: "no build info"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setVersion(t, tt.version, tt.commit, tt.buildTime, tt.info)
+			tt.want.GoVersion = runtime.Version()
+			if got := getVersion(t); got != tt.want {
+				t.Fatalf("/version = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
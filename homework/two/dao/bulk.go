@@ -0,0 +1,60 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidChunk 批量插入的每批大小必须大于0
+var ErrInvalidChunk = errors.New("dao: chunk size must be positive")
+
+const insertUserPrefix = "insert into user (name) values "
+
+// BulkInsertUsers 在一个事务里按每批chunk个，用多行insert插入names，返回插入的总行数
+// names为空时什么都不做；任意一批失败整个事务回滚，错误里带上失败的批次下标
+func BulkInsertUsers(ctx context.Context, db *sql.DB, names []string, chunk int) (int64, error) {
+	if chunk <= 0 {
+		return 0, ErrInvalidChunk
+	}
+	if len(names) == 0 {
+		return 0, nil
+	}
+	var total int64
+	err := WithTx(ctx, db, func(tx *sql.Tx) error {
+		for i := 0; i*chunk < len(names); i++ {
+			batch := names[i*chunk : min((i+1)*chunk, len(names))]
+			n, err := insertUsers(ctx, tx, batch)
+			if err != nil {
+				return fmt.Errorf("insert chunk %d: %w", i, err)
+			}
+			total += n
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// insertUsers 用一条insert语句插入一批用户
+func insertUsers(ctx context.Context, q Querier, names []string) (int64, error) {
+	var b strings.Builder
+	b.WriteString(insertUserPrefix)
+	args := make([]any, len(names))
+	for i, name := range names {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString("(?)")
+		args[i] = name
+	}
+	res, err := q.ExecContext(ctx, b.String(), args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
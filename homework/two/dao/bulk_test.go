@@ -0,0 +1,92 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// execAffected 每条insert影响的行数等于参数个数
+func execAffected(ctx context.Context, c call) (result, error) {
+	return result{rowsAffected: int64(len(c.args))}, nil
+}
+
+func TestBulkInsertUsers(t *testing.T) {
+	names := []string{"a", "b", "c", "d", "e", "f", "g"}
+	tests := []struct {
+		chunk     int
+		wantExecs int
+	}{
+		{chunk: 1, wantExecs: 7},
+		{chunk: 3, wantExecs: 3},
+		{chunk: 7, wantExecs: 1},
+		{chunk: 100, wantExecs: 1},
+	}
+	for _, tt := range tests {
+		db, f := newFakeDB(t, execAffected)
+		n, err := BulkInsertUsers(context.Background(), db, names, tt.chunk)
+		if err != nil || n != int64(len(names)) {
+			t.Fatalf("chunk %d: BulkInsertUsers = %d, %v, want %d", tt.chunk, n, err, len(names))
+		}
+		calls := f.executed()
+		if len(calls) != tt.wantExecs {
+			t.Fatalf("chunk %d: %d Exec calls, want %d", tt.chunk, len(calls), tt.wantExecs)
+		}
+		var got []string
+		for _, c := range calls {
+			if !c.inTx || !strings.HasPrefix(c.query, insertUserPrefix) {
+				t.Fatalf("chunk %d: call %+v not a multi-row insert in the tx", tt.chunk, c)
+			}
+			for _, a := range c.args {
+				got = append(got, a.(string))
+			}
+		}
+		if strings.Join(got, "") != strings.Join(names, "") {
+			t.Fatalf("chunk %d: inserted %v, want %v", tt.chunk, got, names)
+		}
+		if commits, rollbacks := f.txCounts(); commits != 1 || rollbacks != 0 {
+			t.Fatalf("chunk %d: commits = %d, rollbacks = %d, want 1, 0", tt.chunk, commits, rollbacks)
+		}
+	}
+}
+
+func TestBulkInsertUsersRollback(t *testing.T) {
+	errDup := errors.New("duplicate entry")
+	execs := 0
+	db, f := newFakeDB(t, func(ctx context.Context, c call) (result, error) {
+		execs++
+		if execs == 2 {
+			return result{}, errDup
+		}
+		return execAffected(ctx, c)
+	})
+	n, err := BulkInsertUsers(context.Background(), db, []string{"a", "b", "c", "d", "e"}, 2)
+	if !errors.Is(err, errDup) || n != 0 {
+		t.Fatalf("BulkInsertUsers = %d, %v, want 0 and the exec error", n, err)
+	}
+	if !strings.Contains(err.Error(), "insert chunk 1: ") {
+		t.Fatalf("error %q lacks the chunk index", err)
+	}
+	// 第二批失败后不再执行第三批，事务回滚
+	if execs != 2 {
+		t.Fatalf("%d Exec calls, want 2", execs)
+	}
+	if commits, rollbacks := f.txCounts(); commits != 0 || rollbacks != 1 {
+		t.Fatalf("commits = %d, rollbacks = %d, want 0, 1", commits, rollbacks)
+	}
+}
+
+func TestBulkInsertUsersNoop(t *testing.T) {
+	db, f := newFakeDB(t, execAffected)
+	if _, err := BulkInsertUsers(context.Background(), db, []string{"a"}, 0); !errors.Is(err, ErrInvalidChunk) {
+		t.Fatalf("chunk 0: err = %v, want ErrInvalidChunk", err)
+	}
+	if n, err := BulkInsertUsers(context.Background(), db, nil, 10); n != 0 || err != nil {
+		t.Fatalf("empty names: BulkInsertUsers = %d, %v, want 0, nil", n, err)
+	}
+	// 两种情况都不开事务
+	if commits, rollbacks := f.txCounts(); commits != 0 || rollbacks != 0 || len(f.executed()) != 0 {
+		t.Fatalf("commits = %d, rollbacks = %d, calls = %v, want nothing", commits, rollbacks, f.executed())
+	}
+}
@@ -0,0 +1,80 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+)
+
+const (
+	defaultQueryTimeout  = 3 * time.Second
+	defaultSlowThreshold = 500 * time.Millisecond
+)
+
+// DAO 持有数据库连接和查询的默认配置
+type DAO struct {
+	db            *sql.DB
+	queryTimeout  time.Duration
+	slowThreshold time.Duration
+}
+
+// Option 修改DAO的配置
+type Option func(*DAO)
+
+// WithQueryTimeout ctx没有deadline时给每个查询加上的超时时间，d<=0表示不加
+func WithQueryTimeout(d time.Duration) Option {
+	return func(o *DAO) {
+		o.queryTimeout = d
+	}
+}
+
+// WithSlowThreshold 查询耗时超过d时打Warn日志，d<=0表示不记录慢查询
+func WithSlowThreshold(d time.Duration) Option {
+	return func(o *DAO) {
+		o.slowThreshold = d
+	}
+}
+
+// NewDAO 创建DAO，默认超时3s，慢查询阈值500ms
+func NewDAO(db *sql.DB, opts ...Option) *DAO {
+	d := &DAO{
+		db:            db,
+		queryTimeout:  defaultQueryTimeout,
+		slowThreshold: defaultSlowThreshold,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// GetUserName 同包级的GetUserName，ctx里有事务时在事务里查询
+func (d *DAO) GetUserName(ctx context.Context, id int64) (string, error) {
+	ctx, cancel := d.withTimeout(ctx)
+	defer cancel()
+	start := time.Now()
+	name, err := GetUserName(ctx, QuerierFromContext(ctx, d.db), id)
+	d.logSlow(ctx, getUserNameQuery, time.Since(start))
+	return name, err
+}
+
+// withTimeout ctx已经有deadline时以调用方为准，否则加上默认超时
+func (d *DAO) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || d.queryTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, d.queryTimeout)
+}
+
+// logSlow 耗时超过阈值的查询打一条Warn日志
+func (d *DAO) logSlow(ctx context.Context, query string, elapsed time.Duration) {
+	if d.slowThreshold <= 0 || elapsed < d.slowThreshold {
+		return
+	}
+	loggerFrom(ctx).WarnContext(ctx, "slow query",
+		slog.String("query", query),
+		slog.Duration("duration", elapsed),
+		slog.Duration("threshold", d.slowThreshold),
+	)
+}
@@ -0,0 +1,111 @@
+package dao
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestDAODefaultTimeout(t *testing.T) {
+	tests := []struct {
+		name   string
+		ctx    func() (context.Context, context.CancelFunc)
+		opts   []Option
+		within time.Duration // 0表示查询时不应该有deadline
+	}{
+		{
+			name:   "default applied",
+			ctx:    func() (context.Context, context.CancelFunc) { return context.Background(), func() {} },
+			opts:   []Option{WithQueryTimeout(time.Second)},
+			within: time.Second,
+		},
+		{
+			name: "caller deadline kept",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), time.Hour)
+			},
+			opts:   []Option{WithQueryTimeout(time.Second)},
+			within: time.Hour,
+		},
+		{
+			name: "disabled",
+			ctx:  func() (context.Context, context.CancelFunc) { return context.Background(), func() {} },
+			opts: []Option{WithQueryTimeout(0)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deadline time.Time
+			var hasDeadline bool
+			db, _ := newFakeDB(t, func(ctx context.Context, c call) (result, error) {
+				deadline, hasDeadline = ctx.Deadline()
+				return rowsOf("name", "gopher"), nil
+			})
+			ctx, cancel := tt.ctx()
+			defer cancel()
+			start := time.Now()
+			if _, err := NewDAO(db, tt.opts...).GetUserName(ctx, 1); err != nil {
+				t.Fatalf("GetUserName: %v", err)
+			}
+			end := time.Now()
+
+			if hasDeadline != (tt.within > 0) {
+				t.Fatalf("query had deadline = %v, want %v", hasDeadline, tt.within > 0)
+			}
+			// 调用方的一小时deadline不能被默认的1s覆盖
+			if hasDeadline && (deadline.After(end.Add(tt.within)) || deadline.Before(start.Add(tt.within/2))) {
+				t.Fatalf("deadline %v after start, want about %v", deadline.Sub(start), tt.within)
+			}
+		})
+	}
+}
+
+func TestDAOSlowQueryLog(t *testing.T) {
+	tests := []struct {
+		name     string
+		delay    time.Duration
+		wantSlow bool
+	}{
+		{name: "slow", delay: 30 * time.Millisecond, wantSlow: true},
+		{name: "fast", wantSlow: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, _ := newFakeDB(t, func(ctx context.Context, c call) (result, error) {
+				time.Sleep(tt.delay)
+				return rowsOf("name", "gopher"), nil
+			})
+			h := &recordHandler{}
+			ctx := WithLogger(context.Background(), slog.New(h))
+			d := NewDAO(db, WithSlowThreshold(20*time.Millisecond))
+			if _, err := d.GetUserName(ctx, 1); err != nil {
+				t.Fatalf("GetUserName: %v", err)
+			}
+
+			var slow []slog.Record
+			for _, r := range h.records {
+				if r.Message == "slow query" {
+					slow = append(slow, r)
+				}
+			}
+			if (len(slow) == 1) != tt.wantSlow || len(slow) > 1 {
+				t.Fatalf("got %d slow query records, want slow = %v", len(slow), tt.wantSlow)
+			}
+			if !tt.wantSlow {
+				return
+			}
+			r := slow[0]
+			if r.Level != slog.LevelWarn {
+				t.Fatalf("level = %v, want Warn", r.Level)
+			}
+			attrs := attrsOf(r)
+			if q := attrs["query"].String(); q != getUserNameQuery {
+				t.Fatalf("query = %q", q)
+			}
+			if d := attrs["duration"].Duration(); d < tt.delay {
+				t.Fatalf("duration = %v, want >= %v", d, tt.delay)
+			}
+		})
+	}
+}
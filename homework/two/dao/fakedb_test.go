@@ -0,0 +1,174 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+)
+
+// fakeDB 类似sqlmock的假数据库：每条Query/Exec交给handle决定结果，同时记录执行过的语句和事务的提交/回滚
+type fakeDB struct {
+	handle func(ctx context.Context, c call) (result, error)
+
+	mu        sync.Mutex
+	calls     []call
+	commits   int
+	rollbacks int
+}
+
+// call 执行过的一条语句
+type call struct {
+	query string
+	args  []driver.Value
+	// inTx 是否在事务里执行
+	inTx bool
+}
+
+// result 查询返回的列和行；Exec只用rowsAffected
+type result struct {
+	columns      []string
+	rows         [][]driver.Value
+	rowsAffected int64
+}
+
+// newFakeDB handle为nil时所有语句都成功并且没有数据
+func newFakeDB(t *testing.T, handle func(ctx context.Context, c call) (result, error)) (*sql.DB, *fakeDB) {
+	t.Helper()
+	f := &fakeDB{handle: handle}
+	db := sql.OpenDB(f)
+	t.Cleanup(func() { db.Close() })
+	return db, f
+}
+
+// rowsOf 单列的查询结果
+func rowsOf(column string, vals ...driver.Value) result {
+	r := result{columns: []string{column}}
+	for _, v := range vals {
+		r.rows = append(r.rows, []driver.Value{v})
+	}
+	return r
+}
+
+func (f *fakeDB) executed() []call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]call(nil), f.calls...)
+}
+
+func (f *fakeDB) txCounts() (commits, rollbacks int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.commits, f.rollbacks
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{db: f}, nil
+}
+
+func (f *fakeDB) Driver() driver.Driver {
+	return fakeDriver{}
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("fake: use sql.OpenDB")
+}
+
+type fakeConn struct {
+	db   *fakeDB
+	inTx bool
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fake: prepare not supported")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *fakeConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.inTx = true
+	return fakeTx{c}, nil
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	res, err := c.run(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{columns: res.columns, rows: res.rows}, nil
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	res, err := c.run(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(res.rowsAffected), nil
+}
+
+func (c *fakeConn) run(ctx context.Context, query string, named []driver.NamedValue) (result, error) {
+	cl := call{query: query, inTx: c.inTx}
+	for _, a := range named {
+		cl.args = append(cl.args, a.Value)
+	}
+	c.db.mu.Lock()
+	c.db.calls = append(c.db.calls, cl)
+	c.db.mu.Unlock()
+	if c.db.handle == nil {
+		return result{}, nil
+	}
+	return c.db.handle(ctx, cl)
+}
+
+type fakeTx struct {
+	c *fakeConn
+}
+
+func (tx fakeTx) Commit() error {
+	tx.c.inTx = false
+	tx.c.db.mu.Lock()
+	tx.c.db.commits++
+	tx.c.db.mu.Unlock()
+	return nil
+}
+
+func (tx fakeTx) Rollback() error {
+	tx.c.inTx = false
+	tx.c.db.mu.Lock()
+	tx.c.db.rollbacks++
+	tx.c.db.mu.Unlock()
+	return nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
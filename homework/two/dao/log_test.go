@@ -0,0 +1,91 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+)
+
+// recordHandler 把日志记录保存下来，测试里检查级别和属性
+type recordHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *recordHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *recordHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r.Clone())
+	return nil
+}
+
+func (h *recordHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *recordHandler) WithGroup(string) slog.Handler      { return h }
+
+// attrsOf 把记录的属性展开成map
+func attrsOf(r slog.Record) map[string]slog.Value {
+	m := make(map[string]slog.Value)
+	r.Attrs(func(a slog.Attr) bool {
+		m[a.Key] = a.Value
+		return true
+	})
+	return m
+}
+
+func TestGetUserNameLogs(t *testing.T) {
+	tests := []struct {
+		name      string
+		res       result
+		err       error
+		wantLevel slog.Level
+		noRows    bool
+	}{
+		{name: "found", res: rowsOf("name", "gopher"), wantLevel: slog.LevelDebug},
+		{name: "not found", res: rowsOf("name"), wantLevel: slog.LevelDebug, noRows: true},
+		{name: "query error", err: errors.New("syntax error"), wantLevel: slog.LevelWarn},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, _ := newFakeDB(t, func(ctx context.Context, c call) (result, error) {
+				return tt.res, tt.err
+			})
+			h := &recordHandler{}
+			ctx := WithLogger(context.Background(), slog.New(h))
+			GetUserName(ctx, db, 1)
+
+			if len(h.records) != 1 {
+				t.Fatalf("got %d records, want 1", len(h.records))
+			}
+			r := h.records[0]
+			if r.Level != tt.wantLevel {
+				t.Fatalf("level = %v, want %v", r.Level, tt.wantLevel)
+			}
+			attrs := attrsOf(r)
+			if q := attrs["query"].String(); q != getUserNameQuery {
+				t.Fatalf("query = %q", q)
+			}
+			if n := attrs["args"].Int64(); n != 1 {
+				t.Fatalf("args = %d, want 1", n)
+			}
+			if _, ok := attrs["duration"]; !ok {
+				t.Fatal("no duration attribute")
+			}
+			if nr := attrs["no_rows"].Bool(); nr != tt.noRows {
+				t.Fatalf("no_rows = %v, want %v", nr, tt.noRows)
+			}
+			if _, ok := attrs["error"]; ok != (tt.err != nil) {
+				t.Fatalf("error attribute present = %v, want %v", ok, tt.err != nil)
+			}
+		})
+	}
+}
+
+func TestLoggerFromDefault(t *testing.T) {
+	if l := loggerFrom(context.Background()); l != slog.Default() {
+		t.Fatal("loggerFrom without WithLogger is not slog.Default()")
+	}
+}
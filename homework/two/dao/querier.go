@@ -0,0 +1,28 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Querier *sql.DB和*sql.Tx共有的查询方法，dao函数接收它就可以在事务内外通用
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type txKey struct{}
+
+// ContextWithTx 把事务放进ctx，之后QuerierFromContext会返回这个事务
+func ContextWithTx(ctx context.Context, tx *sql.Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// QuerierFromContext ctx里有事务时返回事务，否则返回db
+func QuerierFromContext(ctx context.Context, db *sql.DB) Querier {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok && tx != nil {
+		return tx
+	}
+	return db
+}
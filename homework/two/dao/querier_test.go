@@ -0,0 +1,43 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+func TestQuerierFromContext(t *testing.T) {
+	db, f := newFakeDB(t, func(ctx context.Context, c call) (result, error) {
+		return rowsOf("name", "gopher"), nil
+	})
+
+	// ctx里没有事务时直接用db
+	ctx := context.Background()
+	if q := QuerierFromContext(ctx, db); q != db {
+		t.Fatalf("QuerierFromContext without tx = %T, want the db", q)
+	}
+	if _, err := GetUserName(ctx, QuerierFromContext(ctx, db), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// 有事务时查询跑在事务上
+	err := WithTx(ctx, db, func(tx *sql.Tx) error {
+		txCtx := ContextWithTx(ctx, tx)
+		if q := QuerierFromContext(txCtx, db); q != tx {
+			t.Fatalf("QuerierFromContext with tx = %T, want the tx", q)
+		}
+		_, err := GetUserName(txCtx, QuerierFromContext(txCtx, db), 2)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := f.executed()
+	if len(calls) != 2 || calls[0].inTx || !calls[1].inTx {
+		t.Fatalf("calls = %+v, want the first outside and the second inside the tx", calls)
+	}
+	if commits, _ := f.txCounts(); commits != 1 {
+		t.Fatalf("commits = %d, want 1", commits)
+	}
+}
@@ -0,0 +1,56 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"gostudy/retry"
+)
+
+// QueryWithRetry 执行查询，遇到连接类的临时错误时按backoff给出的间隔重试，backoff为nil时立即重试
+// sql.ErrNoRows和其他逻辑错误直接返回，不重试；等待期间ctx取消则返回ctx.Err()
+// backoff会先被Reset，不能同时用在多个查询上
+func QueryWithRetry(ctx context.Context, db *sql.DB, attempts int, backoff *retry.Backoff, query string, args ...any) (*sql.Rows, error) {
+	if attempts <= 0 {
+		attempts = 1
+	}
+	if backoff != nil {
+		backoff.Reset()
+	}
+	var err error
+	for i := 0; i < attempts; i++ {
+		if i > 0 && backoff != nil {
+			if werr := sleepContext(ctx, backoff.Next()); werr != nil {
+				return nil, werr
+			}
+		}
+		var rows *sql.Rows
+		rows, err = db.QueryContext(ctx, query, args...)
+		if err == nil {
+			return rows, nil
+		}
+		if !isRetryable(err) {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("query failed after %d attempts: %w", attempts, markTransient(db, err))
+}
+
+// isRetryable 只有连接坏掉、连接被重置这类临时错误才值得重试
+func isRetryable(err error) bool {
+	return isTransient(err)
+}
+
+// sleepContext 等待d，ctx先结束则返回ctx.Err()
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
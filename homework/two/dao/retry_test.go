@@ -0,0 +1,77 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+	"time"
+
+	"gostudy/retry"
+)
+
+func TestQueryWithRetryTransient(t *testing.T) {
+	attempts := 0
+	db, _ := newFakeDB(t, func(ctx context.Context, c call) (result, error) {
+		attempts++
+		if attempts <= 2 {
+			return result{}, fmt.Errorf("read: %w", syscall.ECONNRESET)
+		}
+		return rowsOf("name", "gopher"), nil
+	})
+	rows, err := QueryWithRetry(context.Background(), db, 5, retry.NewExponentialBackoff(time.Millisecond, 5*time.Millisecond, 2), getUserNameQuery, 1)
+	if err != nil {
+		t.Fatalf("QueryWithRetry = %v", err)
+	}
+	rows.Close()
+	if attempts != 3 {
+		t.Fatalf("attempts = %d, want 3", attempts)
+	}
+}
+
+func TestQueryWithRetryNoRetry(t *testing.T) {
+	for _, want := range []error{sql.ErrNoRows, errors.New("syntax error")} {
+		attempts := 0
+		db, _ := newFakeDB(t, func(ctx context.Context, c call) (result, error) {
+			attempts++
+			return result{}, want
+		})
+		_, err := QueryWithRetry(context.Background(), db, 5, nil, getUserNameQuery, 1)
+		if !errors.Is(err, want) {
+			t.Fatalf("QueryWithRetry = %v, want %v", err, want)
+		}
+		if attempts != 1 {
+			t.Fatalf("%v retried: attempts = %d, want 1", want, attempts)
+		}
+	}
+}
+
+func TestQueryWithRetryExhausted(t *testing.T) {
+	db, _ := newFakeDB(t, func(ctx context.Context, c call) (result, error) {
+		return result{}, syscall.ECONNRESET
+	})
+	_, err := QueryWithRetry(context.Background(), db, 3, nil, getUserNameQuery, 1)
+	if !errors.Is(err, syscall.ECONNRESET) || !errors.Is(err, ErrTransient) {
+		t.Fatalf("QueryWithRetry = %v, want transient ECONNRESET", err)
+	}
+}
+
+func TestQueryWithRetryContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts := 0
+	db, _ := newFakeDB(t, func(context.Context, call) (result, error) {
+		attempts++
+		// 第一次失败后取消，等待backoff期间应该直接返回
+		cancel()
+		return result{}, syscall.ECONNRESET
+	})
+	_, err := QueryWithRetry(ctx, db, 5, retry.NewExponentialBackoff(time.Second, time.Second, 2), getUserNameQuery, 1)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("QueryWithRetry = %v, want context.Canceled", err)
+	}
+	if attempts != 1 {
+		t.Fatalf("attempts = %d, want 1", attempts)
+	}
+}
@@ -0,0 +1,44 @@
+package dao
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"syscall"
+)
+
+// ErrTransient 连接被关闭、连接池耗尽这类临时错误，稍后重试可能成功
+// 调用方用errors.Is(err, ErrTransient)判断是否重试，原始错误仍然可以用errors.Is匹配
+var ErrTransient = errors.New("dao: transient error")
+
+// isTransient 连接层面的错误，和查不到数据、SQL写错这些逻辑错误区分开
+func isTransient(err error) bool {
+	if err == nil || errors.Is(err, sql.ErrNoRows) {
+		return false
+	}
+	return errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		isTooManyConns(err)
+}
+
+// isTooManyConns 数据库的连接数满了，比如MySQL的Error 1040: Too many connections
+func isTooManyConns(err error) bool {
+	return strings.Contains(strings.ToLower(err.Error()), "too many connections")
+}
+
+// markTransient 临时错误包装成同时匹配ErrTransient和原始错误，其他错误原样返回
+// 怀疑连接池耗尽时带上db的连接池状态，db为nil时不带
+func markTransient(db *sql.DB, err error) error {
+	if !isTransient(err) {
+		return err
+	}
+	if db != nil && isTooManyConns(err) {
+		st := db.Stats()
+		return fmt.Errorf("%w: %w (open=%d in_use=%d idle=%d wait_count=%d)",
+			ErrTransient, err, st.OpenConnections, st.InUse, st.Idle, st.WaitCount)
+	}
+	return fmt.Errorf("%w: %w", ErrTransient, err)
+}
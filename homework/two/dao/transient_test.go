@@ -0,0 +1,59 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestGetUserNameTransient(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		wantTransient bool
+		wantStats     bool
+	}{
+		{name: "conn done", err: sql.ErrConnDone, wantTransient: true},
+		{name: "connection reset", err: fmt.Errorf("read: %w", syscall.ECONNRESET), wantTransient: true},
+		{name: "too many connections", err: errors.New("Error 1040: Too many connections"), wantTransient: true, wantStats: true},
+		{name: "no rows", err: sql.ErrNoRows},
+		{name: "syntax error", err: errors.New("Error 1064: syntax error")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, _ := newFakeDB(t, func(ctx context.Context, c call) (result, error) {
+				return result{}, tt.err
+			})
+			_, err := GetUserName(context.Background(), db, 1)
+			if err == nil {
+				t.Fatal("GetUserName = nil error")
+			}
+			if got := errors.Is(err, ErrTransient); got != tt.wantTransient {
+				t.Fatalf("errors.Is(%v, ErrTransient) = %v, want %v", err, got, tt.wantTransient)
+			}
+			// 包装后原始错误仍然能匹配
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("errors.Is(%v, %v) = false", err, tt.err)
+			}
+			// 怀疑连接池耗尽时错误信息里带上连接池状态
+			if got := strings.Contains(err.Error(), "in_use="); got != tt.wantStats {
+				t.Fatalf("pool stats in %q = %v, want %v", err, got, tt.wantStats)
+			}
+		})
+	}
+}
+
+func TestMarkTransientNil(t *testing.T) {
+	if err := markTransient(nil, nil); err != nil {
+		t.Fatalf("markTransient(nil) = %v, want nil", err)
+	}
+	// 没有db时不带连接池状态，也不能panic
+	err := markTransient(nil, errors.New("too many connections"))
+	if !errors.Is(err, ErrTransient) || strings.Contains(err.Error(), "in_use=") {
+		t.Fatalf("markTransient without db = %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const insertUserQuery = "insert into user(name) values(?)"
+
+func TestWithTx(t *testing.T) {
+	errFn := errors.New("fn failed")
+	tests := []struct {
+		name          string
+		fnErr         error
+		wantCommits   int
+		wantRollbacks int
+	}{
+		{name: "commit on nil", wantCommits: 1},
+		{name: "rollback on error", fnErr: errFn, wantRollbacks: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, f := newFakeDB(t, nil)
+			err := WithTx(context.Background(), db, func(tx *sql.Tx) error {
+				if _, err := tx.Exec(insertUserQuery, "gopher"); err != nil {
+					return err
+				}
+				return tt.fnErr
+			})
+			if !errors.Is(err, tt.fnErr) || (tt.fnErr == nil) != (err == nil) {
+				t.Fatalf("WithTx = %v, want %v", err, tt.fnErr)
+			}
+			if commits, rollbacks := f.txCounts(); commits != tt.wantCommits || rollbacks != tt.wantRollbacks {
+				t.Fatalf("commits = %d, rollbacks = %d, want %d, %d", commits, rollbacks, tt.wantCommits, tt.wantRollbacks)
+			}
+			// fn里的语句跑在事务上
+			if calls := f.executed(); len(calls) != 1 || !calls[0].inTx {
+				t.Fatalf("calls = %+v, want one statement in the tx", calls)
+			}
+		})
+	}
+}
+
+func TestWithTxRepanics(t *testing.T) {
+	db, f := newFakeDB(t, nil)
+	defer func() {
+		if p := recover(); p != "boom" {
+			t.Fatalf("recovered %v, want boom", p)
+		}
+		if commits, rollbacks := f.txCounts(); commits != 0 || rollbacks != 1 {
+			t.Fatalf("commits = %d, rollbacks = %d, want 0, 1", commits, rollbacks)
+		}
+	}()
+	WithTx(context.Background(), db, func(tx *sql.Tx) error {
+		panic("boom")
+	})
+	t.Fatal("WithTx returned after fn panicked")
+}
+
+func TestWithTxBeginCanceled(t *testing.T) {
+	db, f := newFakeDB(t, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	called := false
+	err := WithTx(ctx, db, func(tx *sql.Tx) error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || !strings.HasPrefix(err.Error(), "begin tx: ") {
+		t.Fatalf("WithTx = %v, want wrapped context.Canceled", err)
+	}
+	if called {
+		t.Fatal("fn ran without a transaction")
+	}
+	if commits, rollbacks := f.txCounts(); commits != 0 || rollbacks != 0 {
+		t.Fatalf("commits = %d, rollbacks = %d, want 0, 0", commits, rollbacks)
+	}
+}
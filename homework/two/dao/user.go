@@ -0,0 +1,47 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// ErrUserNotFound 按条件查询的用户不存在
+// 本身wrap了sql.ErrNoRows，调用方用errors.Is判断哪一个都可以
+var ErrUserNotFound = fmt.Errorf("dao: user not found: %w", sql.ErrNoRows)
+
+const getUserNameQuery = "select name from user where id = ?"
+
+// GetUserName 按id查询用户名
+// 查不到时返回wrap了ErrUserNotFound的错误，连接类的临时错误还会wrap ErrTransient，
+// 其他sql错误带上查询条件往上抛
+// q可以是*sql.DB也可以是*sql.Tx，需要跟着ctx里的事务走时传QuerierFromContext(ctx, db)
+func GetUserName(ctx context.Context, q Querier, id int64) (string, error) {
+	var name string
+	start := time.Now()
+	err := q.QueryRowContext(ctx, getUserNameQuery, id).Scan(&name)
+	noRows := errors.Is(err, sql.ErrNoRows)
+
+	log := loggerFrom(ctx)
+	attrs := []any{
+		slog.String("query", getUserNameQuery),
+		slog.Int("args", 1),
+		slog.Duration("duration", time.Since(start)),
+		slog.Bool("no_rows", noRows),
+	}
+	if noRows {
+		// 查不到是预期内的情况，不算错误
+		log.DebugContext(ctx, "query user", attrs...)
+		return "", fmt.Errorf("query user %d: %w", id, ErrUserNotFound)
+	}
+	if err != nil {
+		log.WarnContext(ctx, "query user", append(attrs, slog.Any("error", err))...)
+		db, _ := q.(*sql.DB)
+		return "", fmt.Errorf("query user %d: %w", id, markTransient(db, err))
+	}
+	log.DebugContext(ctx, "query user", attrs...)
+	return name, nil
+}
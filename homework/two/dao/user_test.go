@@ -0,0 +1,48 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestGetUserName(t *testing.T) {
+	db, f := newFakeDB(t, func(ctx context.Context, c call) (result, error) {
+		return rowsOf("name", "gopher"), nil
+	})
+	name, err := GetUserName(context.Background(), db, 1)
+	if err != nil || name != "gopher" {
+		t.Fatalf("GetUserName = %q, %v", name, err)
+	}
+	calls := f.executed()
+	if len(calls) != 1 || calls[0].query != getUserNameQuery || calls[0].args[0] != int64(1) {
+		t.Fatalf("calls = %+v", calls)
+	}
+}
+
+func TestGetUserNameNotFound(t *testing.T) {
+	db, _ := newFakeDB(t, func(ctx context.Context, c call) (result, error) {
+		return rowsOf("name"), nil
+	})
+	_, err := GetUserName(context.Background(), db, 2)
+	// 两个哨兵错误都能匹配
+	if !errors.Is(err, ErrUserNotFound) || !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("GetUserName error = %v, want ErrUserNotFound and sql.ErrNoRows", err)
+	}
+}
+
+func TestGetUserNameQueryError(t *testing.T) {
+	errSyntax := errors.New("syntax error")
+	db, _ := newFakeDB(t, func(ctx context.Context, c call) (result, error) {
+		return result{}, errSyntax
+	})
+	_, err := GetUserName(context.Background(), db, 7)
+	if !errors.Is(err, errSyntax) || errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("GetUserName error = %v, want wrapped syntax error", err)
+	}
+	if !strings.HasPrefix(err.Error(), "query user 7: ") {
+		t.Fatalf("error %q lacks query context", err)
+	}
+}
@@ -1,28 +1,33 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+
+	"gostudy/homework/two/dao"
 )
 //我们在数据库操作的时候，比如 dao 层中当遇到一个 sql.ErrNoRows 的时候，是否应该 Wrap 这个 error，抛给上层。 为什么，应该怎么做请写出代码？
 //应该。
 //sql.go中定义var ErrNoRows = errors.New("sql: no rows in result set")。 按照条件查询的数据不存在，是一个正常的错误。
-//上层应该对该特殊情况进行单独处理，代码如下（）
+//上层应该对该特殊情况进行单独处理，代码如下（dao/user.go）
 func main() {
 	db,err := sql.Open("mysql","")
 	if err != nil{
-		fmt.Println(err)
+		log.Fatal(err)
 	}
 
-	res,err :=db.Query("select name from user where id=1")
+	name,err := dao.NewDAO(db).GetUserName(context.Background(), 1)
 
 	if err != nil {
-		if err == sql.ErrNoRows {
-			//wrap这个error，抛给上层
-		} else {
-			log.Fatal(err)
+		if errors.Is(err, dao.ErrUserNotFound) {
+			//dao层已经wrap，这里单独处理数据不存在的情况
+			fmt.Println("user not found:", err)
+			return
 		}
+		log.Fatal(err)
 	}
-	fmt.Println(*res)
+	fmt.Println(name)
 }
@@ -0,0 +1,60 @@
+package retry
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff 指数增长、带完全随机抖动（full jitter）的重试间隔
+// 内部记录了当前进行到第几次，不能在多个goroutine之间共用，每次重试流程用一个新的或者先Reset
+type Backoff struct {
+	base   time.Duration
+	max    time.Duration
+	factor float64
+	cur    time.Duration
+}
+
+// NewExponentialBackoff 第一次的上限是base，之后每次乘以factor，最多到max
+// factor<1时按2处理，max<base时按base处理
+func NewExponentialBackoff(base, max time.Duration, factor float64) *Backoff {
+	if factor < 1 {
+		factor = 2
+	}
+	if max < base {
+		max = base
+	}
+	return &Backoff{base: base, max: max, factor: factor, cur: base}
+}
+
+// Next 返回下一次要等待的时间，在[0, 当前上限]之间随机，然后把上限乘以factor
+func (b *Backoff) Next() time.Duration {
+	d := b.cur
+	// 先用float64比较，超过max的值转成Duration会溢出
+	if next := float64(b.cur) * b.factor; next >= float64(b.max) {
+		b.cur = b.max
+	} else {
+		b.cur = time.Duration(next)
+	}
+	if d <= 0 {
+		return 0
+	}
+	if d == math.MaxInt64 {
+		// d+1会溢出，少一纳秒无所谓
+		return rand.N(d)
+	}
+	return rand.N(d + 1)
+}
+
+// Reset 重新从base开始
+func (b *Backoff) Reset() {
+	b.cur = b.base
+}
+
+// Func 先Reset，再把b包装成Retry使用的BackoffFunc，忽略attempt参数，按调用顺序增长
+func (b *Backoff) Func() BackoffFunc {
+	b.Reset()
+	return func(int) time.Duration {
+		return b.Next()
+	}
+}
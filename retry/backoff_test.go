@@ -0,0 +1,109 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	b := NewExponentialBackoff(10*time.Millisecond, 40*time.Millisecond, 2)
+	for _, max := range []time.Duration{10, 20, 40, 40} {
+		if d := b.Next(); d < 0 || d > max*time.Millisecond {
+			t.Fatalf("Next = %v, want in [0, %v]", d, max*time.Millisecond)
+		}
+	}
+	b.Reset()
+	if b.cur != 10*time.Millisecond {
+		t.Fatalf("cur after Reset = %v, want 10ms", b.cur)
+	}
+}
+
+// meanNext 用samples个新的Backoff，求第1到第n次Next的平均值
+func meanNext(samples, n int, newBackoff func() *Backoff) []time.Duration {
+	sums := make([]time.Duration, n)
+	for i := 0; i < samples; i++ {
+		b := newBackoff()
+		for j := range sums {
+			sums[j] += b.Next()
+		}
+	}
+	for j := range sums {
+		sums[j] /= time.Duration(samples)
+	}
+	return sums
+}
+
+func TestExponentialBackoffGrowth(t *testing.T) {
+	const (
+		base = 100 * time.Millisecond
+		max  = time.Second
+	)
+	means := meanNext(2000, 8, func() *Backoff { return NewExponentialBackoff(base, max, 2) })
+	// 完全随机抖动的平均值是上限的一半：50ms、100ms、200ms、400ms，之后停在500ms
+	caps := []time.Duration{base, 2 * base, 4 * base, 8 * base, max, max, max, max}
+	for i, mean := range means {
+		want := caps[i] / 2
+		if mean < want*8/10 || mean > want*12/10 {
+			t.Fatalf("mean of Next #%d = %v, want about %v", i+1, mean, want)
+		}
+	}
+}
+
+func TestExponentialBackoffNeverExceedsMax(t *testing.T) {
+	tests := []struct {
+		name      string
+		base, max time.Duration
+		factor    float64
+	}{
+		{name: "normal", base: time.Millisecond, max: 50 * time.Millisecond, factor: 2},
+		{name: "factor below 1", base: time.Millisecond, max: 50 * time.Millisecond, factor: 0.5},
+		{name: "max below base", base: 50 * time.Millisecond, max: time.Millisecond, factor: 2},
+		// 一直乘下去会溢出，不能因此变成负数或者超过max
+		{name: "overflow", base: time.Hour, max: 1<<63 - 1, factor: 1e6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewExponentialBackoff(tt.base, tt.max, tt.factor)
+			limit := max(tt.max, tt.base)
+			for i := 0; i < 100; i++ {
+				if d := b.Next(); d < 0 || d > limit {
+					t.Fatalf("Next #%d = %v, want in [0, %v]", i+1, d, limit)
+				}
+			}
+		})
+	}
+}
+
+func TestExponentialBackoffReset(t *testing.T) {
+	const base = 10 * time.Millisecond
+	means := meanNext(2000, 1, func() *Backoff {
+		b := NewExponentialBackoff(base, time.Second, 2)
+		for i := 0; i < 10; i++ {
+			b.Next()
+		}
+		b.Reset()
+		return b
+	})
+	// Reset之后第一次又回到[0, base]
+	if means[0] > base*6/10 {
+		t.Fatalf("mean of Next after Reset = %v, want about %v", means[0], base/2)
+	}
+}
+
+func TestRetryWithBackoff(t *testing.T) {
+	b := NewExponentialBackoff(time.Millisecond, 2*time.Millisecond, 2)
+	// Func先Reset，用过的Backoff交给下一次Retry也从base开始
+	for i := 0; i < 5; i++ {
+		b.Next()
+	}
+	attempts := 0
+	err := Retry(context.Background(), 3, b.Func(), func(context.Context) error {
+		attempts++
+		return errors.New("fail")
+	})
+	if err == nil || attempts != 3 {
+		t.Fatalf("Retry = %v after %d attempts, want error after 3", err, attempts)
+	}
+}
@@ -0,0 +1,88 @@
+// Package retry 通用的带context的重试工具，DB和HTTP代码都可以用
+package retry
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryStop fn返回包装了RetryStop的错误时立即停止重试
+var RetryStop = errors.New("retry: stop")
+
+// BackoffFunc 根据第几次失败（从1开始）计算下次重试前要等多久
+type BackoffFunc func(attempt int) time.Duration
+
+// Constant 每次都等d
+func Constant(d time.Duration) BackoffFunc {
+	return func(int) time.Duration {
+		return d
+	}
+}
+
+// Linear 第n次失败后等n*step
+func Linear(step time.Duration) BackoffFunc {
+	return func(attempt int) time.Duration {
+		return time.Duration(attempt) * step
+	}
+}
+
+// ExponentialJitter 第n次失败后在[d/2, d]之间随机等待，d=base*2^(n-1)，最大不超过max
+func ExponentialJitter(base, max time.Duration) BackoffFunc {
+	return func(attempt int) time.Duration {
+		d := base
+		for i := 1; i < attempt && d < max; i++ {
+			d *= 2
+		}
+		if d > max {
+			d = max
+		}
+		if d <= 0 {
+			return 0
+		}
+		half := d / 2
+		return half + rand.N(d-half+1)
+	}
+}
+
+// Retry 最多执行fn attempts次，直到成功为止，两次之间按backoff等待
+// 全部失败返回最后一次的错误；fn返回包装了RetryStop的错误时直接返回该错误；
+// 等待期间ctx结束则返回ctx.Err()
+func Retry(ctx context.Context, attempts int, backoff BackoffFunc, fn func(ctx context.Context) error) error {
+	if attempts <= 0 {
+		attempts = 1
+	}
+	var err error
+	for i := 1; i <= attempts; i++ {
+		if err = fn(ctx); err == nil {
+			return nil
+		}
+		if errors.Is(err, RetryStop) || i == attempts {
+			return err
+		}
+		var d time.Duration
+		if backoff != nil {
+			d = backoff(i)
+		}
+		if werr := sleepContext(ctx, d); werr != nil {
+			return werr
+		}
+	}
+	return err
+}
+
+// sleepContext 等待d，ctx先结束则返回ctx.Err()
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,96 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	errFail := errors.New("fail")
+	tests := []struct {
+		name     string
+		attempts int
+		// failUntil 前failUntil次调用返回errFail
+		failUntil int
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "second attempt", attempts: 3, failUntil: 1, wantCalls: 2},
+		{name: "exhausted", attempts: 3, failUntil: 10, wantCalls: 3, wantErr: errFail},
+		{name: "non-positive attempts", attempts: 0, failUntil: 10, wantCalls: 1, wantErr: errFail},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := Retry(context.Background(), tt.attempts, Constant(time.Millisecond), func(ctx context.Context) error {
+				calls++
+				if calls <= tt.failUntil {
+					return fmt.Errorf("call %d: %w", calls, errFail)
+				}
+				return nil
+			})
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil) != (err == nil) {
+				t.Fatalf("Retry = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Fatalf("fn called %d times, want %d", calls, tt.wantCalls)
+			}
+			// 全部失败时返回的是最后一次的错误
+			if tt.wantErr != nil && err.Error() != fmt.Sprintf("call %d: fail", calls) {
+				t.Fatalf("Retry = %v, want the last error", err)
+			}
+		})
+	}
+}
+
+func TestRetryCancelBetweenAttempts(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := Retry(ctx, 5, Constant(time.Hour), func(ctx context.Context) error {
+		calls++
+		cancel()
+		return errors.New("fail")
+	})
+	if !errors.Is(err, context.Canceled) || calls != 1 {
+		t.Fatalf("Retry = %v after %d calls, want Canceled after 1", err, calls)
+	}
+}
+
+func TestRetryStop(t *testing.T) {
+	calls := 0
+	err := Retry(context.Background(), 5, Constant(time.Millisecond), func(ctx context.Context) error {
+		calls++
+		return fmt.Errorf("bad request: %w", RetryStop)
+	})
+	if !errors.Is(err, RetryStop) || calls != 1 {
+		t.Fatalf("Retry = %v after %d calls, want RetryStop after 1", err, calls)
+	}
+}
+
+func TestBackoffFuncs(t *testing.T) {
+	if d := Constant(time.Second)(3); d != time.Second {
+		t.Fatalf("Constant(1s)(3) = %v", d)
+	}
+	if d := Linear(time.Second)(3); d != 3*time.Second {
+		t.Fatalf("Linear(1s)(3) = %v", d)
+	}
+	exp := ExponentialJitter(10*time.Millisecond, 50*time.Millisecond)
+	tests := []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{1, 5 * time.Millisecond, 10 * time.Millisecond},
+		{3, 20 * time.Millisecond, 40 * time.Millisecond},
+		{10, 25 * time.Millisecond, 50 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 100; i++ {
+			if d := exp(tt.attempt); d < tt.min || d > tt.max {
+				t.Fatalf("ExponentialJitter attempt %d = %v, want in [%v, %v]", tt.attempt, d, tt.min, tt.max)
+			}
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package sync
+
+import "sync/atomic"
+
+// An Atomic holds a value of type T that is replaced as a whole rather
+// than modified in place. Loads and stores are lock-free: each Store
+// publishes a pointer to a fresh copy, so a Load never observes a
+// partially written value.
+//
+// The zero value holds the zero T. An Atomic must not be copied after
+// first use.
+type Atomic[T any] struct {
+	p atomic.Pointer[T]
+}
+
+// Load returns the current value.
+func (a *Atomic[T]) Load() T {
+	if p := a.p.Load(); p != nil {
+		return *p
+	}
+	var zero T
+	return zero
+}
+
+// Store sets the value to v.
+func (a *Atomic[T]) Store(v T) {
+	a.p.Store(&v)
+}
+
+// Swap sets the value to v and returns the previous value.
+func (a *Atomic[T]) Swap(v T) T {
+	if old := a.p.Swap(&v); old != nil {
+		return *old
+	}
+	var zero T
+	return zero
+}
+
+// CompareAndSwap sets the value to new if the current value equals old,
+// and reports whether it did. Like atomic.Value.CompareAndSwap, it panics
+// if T is not comparable.
+func (a *Atomic[T]) CompareAndSwap(old, new T) bool {
+	for {
+		p := a.p.Load()
+		var cur T
+		if p != nil {
+			cur = *p
+		}
+		// T不一定是comparable，转成any比较，不可比较的类型会panic
+		if any(cur) != any(old) {
+			return false
+		}
+		// 指针没变说明期间没有别的写入，换成新值；否则重新比较
+		if a.p.CompareAndSwap(p, &new) {
+			return true
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package sync
+
+import (
+	"fmt"
+	"testing"
+)
+
+// pair is written as a whole; a Load that returns A != B saw a torn value.
+type pair struct {
+	A, B int
+	Name string
+}
+
+func TestAtomicZeroValue(t *testing.T) {
+	var a Atomic[pair]
+	if v := a.Load(); v != (pair{}) {
+		t.Fatalf("Load of zero Atomic = %+v, want zero", v)
+	}
+	if old := a.Swap(pair{A: 1, B: 1}); old != (pair{}) {
+		t.Fatalf("first Swap returned %+v, want zero", old)
+	}
+	if old := a.Swap(pair{A: 2, B: 2}); old != (pair{A: 1, B: 1}) {
+		t.Fatalf("Swap returned %+v, want {1 1}", old)
+	}
+	a.Store(pair{A: 3, B: 3})
+	if v := a.Load(); v != (pair{A: 3, B: 3}) {
+		t.Fatalf("Load = %+v, want {3 3}", v)
+	}
+}
+
+func TestAtomicCompareAndSwap(t *testing.T) {
+	var a Atomic[int]
+	// The zero value compares equal to the zero T.
+	if !a.CompareAndSwap(0, 1) {
+		t.Fatal("CompareAndSwap(0, 1) on zero Atomic failed")
+	}
+	if a.CompareAndSwap(0, 2) {
+		t.Fatal("CompareAndSwap with stale old value succeeded")
+	}
+	if v := a.Load(); v != 1 {
+		t.Fatalf("Load after failed CAS = %d, want 1", v)
+	}
+	if !a.CompareAndSwap(1, 2) || a.Load() != 2 {
+		t.Fatalf("CompareAndSwap(1, 2) failed, Load = %d", a.Load())
+	}
+}
+
+func TestAtomicCompareAndSwapNotComparable(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("CompareAndSwap on a non-comparable type did not panic")
+		}
+	}()
+	var a Atomic[[]int]
+	a.Store([]int{1})
+	a.CompareAndSwap([]int{1}, []int{2})
+}
+
+// Run with -race.
+func TestAtomicConcurrent(t *testing.T) {
+	const (
+		writers = 4
+		readers = 4
+		stores  = 1000
+	)
+	var a Atomic[pair]
+	a.Store(pair{Name: "0"})
+	var wg WaitGroup
+	done := make(chan struct{})
+	errs := make(chan error, readers)
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if v := a.Load(); v.A != v.B || v.Name != fmt.Sprint(v.A) {
+					errs <- fmt.Errorf("torn Load: %+v", v)
+					return
+				}
+			}
+		}()
+	}
+	var writersWG WaitGroup
+	for i := 0; i < writers; i++ {
+		writersWG.Add(1)
+		go func() {
+			defer writersWG.Done()
+			for j := 1; j <= stores; j++ {
+				v := i*stores + j
+				if j%2 == 0 {
+					a.Store(pair{A: v, B: v, Name: fmt.Sprint(v)})
+				} else {
+					a.Swap(pair{A: v, B: v, Name: fmt.Sprint(v)})
+				}
+			}
+		}()
+	}
+	writersWG.Wait()
+	close(done)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+}
+
+func TestAtomicCompareAndSwapConcurrent(t *testing.T) {
+	const (
+		goroutines = 8
+		increments = 500
+	)
+	var a Atomic[int]
+	var wg WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < increments; j++ {
+				for {
+					old := a.Load()
+					if a.CompareAndSwap(old, old+1) {
+						break
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if v := a.Load(); v != goroutines*increments {
+		t.Fatalf("counter = %d, want %d", v, goroutines*increments)
+	}
+}
@@ -0,0 +1,48 @@
+package sync
+
+import "context"
+
+// A BoundedGroup runs functions in goroutines, like errgroup.Group, but
+// never more than a fixed number at a time.
+//
+// A BoundedGroup must not be copied after first use.
+type BoundedGroup struct {
+	sem *Semaphore
+	wg  WaitGroup
+
+	errOnce Once
+	err     error
+}
+
+// NewBoundedGroup returns a BoundedGroup that runs at most limit functions
+// concurrently. It panics if limit < 1.
+func NewBoundedGroup(limit int) *BoundedGroup {
+	if limit < 1 {
+		panic("sync: NewBoundedGroup with non-positive limit")
+	}
+	return &BoundedGroup{sem: NewSemaphore(limit)}
+}
+
+// Go calls fn in a new goroutine. If limit goroutines of the group are
+// already running, Go blocks until one of them returns.
+func (g *BoundedGroup) Go(fn func() error) {
+	// Background永远不会结束，Acquire只会在拿到名额后返回
+	g.sem.Acquire(context.Background())
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		defer g.sem.Release()
+		if err := fn(); err != nil {
+			g.errOnce.Do(func() {
+				g.err = err
+			})
+		}
+	}()
+}
+
+// Wait blocks until all functions started with Go have returned, and
+// returns the first non-nil error, if any, they returned.
+func (g *BoundedGroup) Wait() error {
+	g.wg.Wait()
+	return g.err
+}
@@ -0,0 +1,63 @@
+package sync
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBoundedGroupLimit(t *testing.T) {
+	const limit, tasks = 3, 20
+	g := NewBoundedGroup(limit)
+	var running, peak, done atomic.Int32
+	for i := 0; i < tasks; i++ {
+		g.Go(func() error {
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			running.Add(-1)
+			done.Add(1)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		t.Fatalf("Wait = %v, want nil", err)
+	}
+	if n := done.Load(); n != tasks {
+		t.Fatalf("%d functions ran, want %d", n, tasks)
+	}
+	if p := peak.Load(); p > limit {
+		t.Fatalf("peak concurrency = %d, want <= %d", p, limit)
+	}
+}
+
+func TestBoundedGroupError(t *testing.T) {
+	g := NewBoundedGroup(2)
+	errTask := errors.New("task failed")
+	for i := 0; i < 5; i++ {
+		g.Go(func() error {
+			if i == 3 {
+				return errTask
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != errTask {
+		t.Fatalf("Wait = %v, want %v", err, errTask)
+	}
+}
+
+func TestNewBoundedGroupPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("NewBoundedGroup(0) did not panic")
+		}
+	}()
+	NewBoundedGroup(0)
+}
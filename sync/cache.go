@@ -0,0 +1,110 @@
+package sync
+
+import (
+	"errors"
+	"time"
+)
+
+// errComputePanicked is returned to callers that waited on a compute
+// function that panicked.
+var errComputePanicked = errors.New("sync: Cache compute panicked")
+
+// A Cache memoizes the results of computing values by key. Hits are served
+// under a read lock; a miss takes the write lock only long enough to
+// register the computation, so at most one compute runs per key at a time
+// and concurrent callers for the same key wait for its result.
+//
+// Errors are not cached: a failed compute is retried by the next caller.
+type Cache[K comparable, V any] struct {
+	mu      RWMutex
+	ttl     time.Duration
+	entries map[K]*cacheEntry[V]
+}
+
+type cacheEntry[V any] struct {
+	// ready在计算完成后关闭，之后val/err/expires只读
+	ready   chan struct{}
+	val     V
+	err     error
+	expires time.Time
+}
+
+// NewCache returns an empty Cache. If ttl > 0, entries expire ttl after
+// they were computed and are recomputed on the next access; otherwise
+// they never expire.
+func NewCache[K comparable, V any](ttl time.Duration) *Cache[K, V] {
+	return &Cache[K, V]{
+		ttl:     ttl,
+		entries: make(map[K]*cacheEntry[V]),
+	}
+}
+
+// GetOrCompute returns the cached value for key, calling compute to fill
+// it if it is missing or expired. compute is called at most once at a time
+// for each key, even when many goroutines miss concurrently.
+func (c *Cache[K, V]) GetOrCompute(key K, compute func() (V, error)) (V, error) {
+	c.mu.RLock()
+	e, ok := c.entries[key]
+	c.mu.RUnlock()
+	if ok && c.fresh(e) {
+		<-e.ready
+		return e.val, e.err
+	}
+
+	c.mu.Lock()
+	// 拿写锁之前可能已经有别的goroutine登记了计算
+	e, ok = c.entries[key]
+	if ok && c.fresh(e) {
+		c.mu.Unlock()
+		<-e.ready
+		return e.val, e.err
+	}
+	e = &cacheEntry[V]{ready: make(chan struct{})}
+	c.entries[key] = e
+	c.mu.Unlock()
+
+	// compute不在锁里执行，其他key不受影响
+	// compute panic时e.err保持errComputePanicked，等待的调用方拿到这个错误
+	e.err = errComputePanicked
+	defer func() {
+		if e.err != nil {
+			c.mu.Lock()
+			if c.entries[key] == e {
+				delete(c.entries, key)
+			}
+			c.mu.Unlock()
+		}
+		close(e.ready)
+	}()
+	e.val, e.err = compute()
+	if e.err == nil && c.ttl > 0 {
+		e.expires = time.Now().Add(c.ttl)
+	}
+	return e.val, e.err
+}
+
+// fresh reports whether e is still usable: either its computation is in
+// flight or it finished successfully and has not expired.
+func (c *Cache[K, V]) fresh(e *cacheEntry[V]) bool {
+	select {
+	case <-e.ready:
+	default:
+		return true
+	}
+	return e.err == nil && (e.expires.IsZero() || time.Now().Before(e.expires))
+}
+
+// Delete removes key from the cache.
+func (c *Cache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	delete(c.entries, key)
+	c.mu.Unlock()
+}
+
+// Len returns the number of entries, including expired ones that have
+// not been recomputed yet.
+func (c *Cache[K, V]) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.entries)
+}
@@ -0,0 +1,108 @@
+package sync
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCacheSingleFlight(t *testing.T) {
+	c := NewCache[string, int](0)
+	var calls atomic.Int32
+	release := make(chan struct{})
+	compute := func() (int, error) {
+		calls.Add(1)
+		<-release
+		return 42, nil
+	}
+
+	const goroutines = 10
+	results := make(chan int, goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			v, err := c.GetOrCompute("k", compute)
+			if err != nil {
+				t.Errorf("GetOrCompute = %v", err)
+			}
+			results <- v
+		}()
+	}
+	// Give every goroutine a chance to miss before the compute finishes.
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	for i := 0; i < goroutines; i++ {
+		if v := <-results; v != 42 {
+			t.Fatalf("GetOrCompute = %d, want 42", v)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("compute called %d times, want 1", n)
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	c := NewCache[string, int](20 * time.Millisecond)
+	calls := 0
+	compute := func() (int, error) {
+		calls++
+		return calls, nil
+	}
+	if v, _ := c.GetOrCompute("k", compute); v != 1 {
+		t.Fatalf("first GetOrCompute = %d, want 1", v)
+	}
+	if v, _ := c.GetOrCompute("k", compute); v != 1 {
+		t.Fatalf("GetOrCompute before expiry = %d, want the cached 1", v)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if v, _ := c.GetOrCompute("k", compute); v != 2 {
+		t.Fatalf("GetOrCompute after expiry = %d, want the recomputed 2", v)
+	}
+}
+
+func TestCacheErrorNotCached(t *testing.T) {
+	c := NewCache[int, string](0)
+	errCompute := errors.New("compute failed")
+	if _, err := c.GetOrCompute(1, func() (string, error) { return "", errCompute }); err != errCompute {
+		t.Fatalf("GetOrCompute = %v, want %v", err, errCompute)
+	}
+	if n := c.Len(); n != 0 {
+		t.Fatalf("Len after a failed compute = %d, want 0", n)
+	}
+	if v, err := c.GetOrCompute(1, func() (string, error) { return "ok", nil }); err != nil || v != "ok" {
+		t.Fatalf("GetOrCompute after failure = %q, %v, want ok", v, err)
+	}
+	c.Delete(1)
+	if n := c.Len(); n != 0 {
+		t.Fatalf("Len after Delete = %d, want 0", n)
+	}
+}
+
+func TestCacheComputePanics(t *testing.T) {
+	c := NewCache[int, int](0)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	waiter := make(chan error, 1)
+	go func() {
+		defer func() { recover() }()
+		c.GetOrCompute(1, func() (int, error) {
+			close(started)
+			<-release
+			panic("compute")
+		})
+	}()
+	<-started
+	go func() {
+		_, err := c.GetOrCompute(1, func() (int, error) { return 0, nil })
+		waiter <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	// The waiter gets an error instead of hanging, and the key is retried.
+	if err := <-waiter; err != errComputePanicked {
+		t.Fatalf("waiter got %v, want errComputePanicked", err)
+	}
+	if v, err := c.GetOrCompute(1, func() (int, error) { return 7, nil }); err != nil || v != 7 {
+		t.Fatalf("GetOrCompute after panic = %d, %v, want 7", v, err)
+	}
+}
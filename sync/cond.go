@@ -0,0 +1,76 @@
+package sync
+
+import "context"
+
+// Cond is a condition variable like the standard library's sync.Cond,
+// except that Wait takes a context and can be abandoned.
+//
+// Waiters are kept in a FIFO list of channels guarded by a Mutex instead
+// of being parked on runtime semaphores, so Signal wakes the goroutine
+// that has waited longest.
+type Cond struct {
+	// L is held while observing or changing the condition.
+	L Locker
+
+	mu      Mutex
+	waiters []chan struct{}
+}
+
+// NewCond returns a new Cond with Locker l.
+func NewCond(l Locker) *Cond {
+	return &Cond{L: l}
+}
+
+// Wait atomically unlocks c.L and suspends the calling goroutine until it
+// is woken by Signal or Broadcast or ctx is done. c.L is locked again
+// before Wait returns, in both cases.
+//
+// If ctx is done first, Wait returns ctx.Err(). As with sync.Cond, the
+// caller should re-check the condition in a loop after Wait returns nil.
+func (c *Cond) Wait(ctx context.Context) error {
+	ch := make(chan struct{})
+	c.mu.Lock()
+	c.waiters = append(c.waiters, ch)
+	c.mu.Unlock()
+
+	c.L.Unlock()
+	defer c.L.Lock()
+
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, w := range c.waiters {
+		if w == ch {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			return ctx.Err()
+		}
+	}
+	// 不在列表里说明取消的同时已经被Signal选中了，当作被唤醒，避免这次Signal丢失
+	return nil
+}
+
+// Signal wakes one goroutine waiting on c, if there is any.
+func (c *Cond) Signal() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.waiters) == 0 {
+		return
+	}
+	close(c.waiters[0])
+	c.waiters = c.waiters[1:]
+}
+
+// Broadcast wakes all goroutines waiting on c.
+func (c *Cond) Broadcast() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, w := range c.waiters {
+		close(w)
+	}
+	c.waiters = nil
+}
@@ -0,0 +1,111 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// waitersOf reports how many goroutines are parked in c.Wait.
+func waitersOf(c *Cond) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// startWaiters starts n goroutines blocked in c.Wait and returns the
+// channel their results are sent on once all of them are parked.
+func startWaiters(t *testing.T, c *Cond, ctx context.Context, n int) <-chan error {
+	t.Helper()
+	done := make(chan error, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			c.L.Lock()
+			err := c.Wait(ctx)
+			c.L.Unlock()
+			done <- err
+		}()
+	}
+	deadline := time.Now().Add(time.Second)
+	for waitersOf(c) != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d waiters parked, want %d", waitersOf(c), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return done
+}
+
+func TestCondSignal(t *testing.T) {
+	c := NewCond(&Mutex{})
+	done := startWaiters(t, c, context.Background(), 2)
+
+	c.Signal()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Wait = %v, want nil", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Signal did not wake a waiter")
+	}
+
+	// Signal wakes exactly one goroutine.
+	select {
+	case <-done:
+		t.Fatal("Signal woke more than one waiter")
+	case <-time.After(20 * time.Millisecond):
+	}
+	c.Signal()
+	if err := <-done; err != nil {
+		t.Fatalf("Wait = %v, want nil", err)
+	}
+}
+
+func TestCondBroadcast(t *testing.T) {
+	const n = 5
+	c := NewCond(&Mutex{})
+	done := startWaiters(t, c, context.Background(), n)
+
+	c.Broadcast()
+	for i := 0; i < n; i++ {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("Wait = %v, want nil", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Broadcast woke %d of %d waiters", i, n)
+		}
+	}
+	if w := waitersOf(c); w != 0 {
+		t.Fatalf("%d waiters left after Broadcast", w)
+	}
+}
+
+func TestCondWaitDeadline(t *testing.T) {
+	l := &Mutex{}
+	c := NewCond(l)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	l.Lock()
+	start := time.Now()
+	err := c.Wait(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait = %v, want DeadlineExceeded", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("Wait returned after %v, want about 10ms", d)
+	}
+	// Wait re-locks c.L before returning, even on cancellation.
+	if l.TryLock() {
+		t.Fatal("c.L not held after Wait returned")
+	}
+	l.Unlock()
+
+	if w := waitersOf(c); w != 0 {
+		t.Fatalf("cancelled waiter still queued: %d waiters", w)
+	}
+}
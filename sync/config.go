@@ -0,0 +1,37 @@
+package sync
+
+// A Config holds a settings value of type S that is read often and
+// replaced rarely, the canonical use case for RWMutex.
+//
+// Get returns a copy taken under the read lock, and Update edits a copy
+// under the write lock before swapping it in, so readers never observe a
+// partially applied update. The copy is shallow: maps, slices and
+// pointers inside S are shared, so fn passed to Update must replace them
+// rather than modify them in place.
+type Config[S any] struct {
+	mu RWMutex
+	v  S
+}
+
+// NewConfig returns a Config holding initial.
+func NewConfig[S any](initial S) *Config[S] {
+	return &Config[S]{v: initial}
+}
+
+// Get returns a snapshot of the current settings.
+func (c *Config[S]) Get() S {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.v
+}
+
+// Update calls fn with a copy of the current settings and then stores
+// the modified copy. Concurrent Updates are applied one at a time.
+func (c *Config[S]) Update(fn func(*S)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// 改的是副本，fn中途panic也不会留下改了一半的配置
+	next := c.v
+	fn(&next)
+	c.v = next
+}
@@ -0,0 +1,94 @@
+package sync
+
+import (
+	"fmt"
+	"testing"
+)
+
+// testSettings has fields that an Update always changes together, so a
+// reader seeing them disagree has observed a torn update.
+type testSettings struct {
+	Version int
+	Name    string
+	Limit   int
+}
+
+func TestConfigGetUpdate(t *testing.T) {
+	c := NewConfig(testSettings{Version: 1, Name: "v1", Limit: 10})
+	got := c.Get()
+	got.Limit = 99 // modifying a snapshot must not affect the Config
+	c.Update(func(s *testSettings) {
+		s.Version++
+		s.Name = "v2"
+	})
+	if want := (testSettings{Version: 2, Name: "v2", Limit: 10}); c.Get() != want {
+		t.Fatalf("Get = %+v, want %+v", c.Get(), want)
+	}
+}
+
+func TestConfigUpdatePanics(t *testing.T) {
+	c := NewConfig(testSettings{Version: 1, Name: "v1"})
+	func() {
+		defer func() { recover() }()
+		c.Update(func(s *testSettings) {
+			s.Version = 2
+			panic("half way")
+		})
+	}()
+	// The copy being edited is discarded, and the lock is released.
+	if want := (testSettings{Version: 1, Name: "v1"}); c.Get() != want {
+		t.Fatalf("Get after panicking Update = %+v, want %+v", c.Get(), want)
+	}
+}
+
+// Run with -race.
+func TestConfigConcurrent(t *testing.T) {
+	const (
+		readers = 8
+		updates = 500
+	)
+	c := NewConfig(testSettings{Version: 0, Name: "v0", Limit: 0})
+	done := make(chan struct{})
+	errs := make(chan error, readers)
+	var wg WaitGroup
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			last := -1
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				s := c.Get()
+				if s.Name != fmt.Sprintf("v%d", s.Version) || s.Limit != 10*s.Version {
+					errs <- fmt.Errorf("torn read: %+v", s)
+					return
+				}
+				if s.Version < last {
+					errs <- fmt.Errorf("version went back from %d to %d", last, s.Version)
+					return
+				}
+				last = s.Version
+			}
+		}()
+	}
+	for i := 0; i < updates; i++ {
+		c.Update(func(s *testSettings) {
+			s.Version++
+			s.Name = fmt.Sprintf("v%d", s.Version)
+			s.Limit = 10 * s.Version
+		})
+	}
+	close(done)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+	if v := c.Get().Version; v != updates {
+		t.Fatalf("Version = %d, want %d", v, updates)
+	}
+}
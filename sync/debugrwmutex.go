@@ -0,0 +1,111 @@
+package sync
+
+import (
+	"fmt"
+	"log"
+)
+
+// A DebugRWMutex is a RWMutex that tracks which goroutines hold it, to
+// find locking mistakes during development. It warns when:
+//
+//   - the write lock is released by a goroutine other than the one that
+//     acquired it, which RWMutex allows but is usually a bug;
+//   - RUnlock is called by a goroutine that holds no read lock, which is
+//     either a double RUnlock or a read lock handed between goroutines;
+//   - Unlock is called while the write lock is not held.
+//
+// In the last two cases the call is ignored. Releasing the lock anyway
+// would drop a read lock some other goroutine still holds, or crash the
+// program as RWMutex does. DebugRWMutex therefore does not support read
+// locks that are released by a goroutine other than the one that took
+// them.
+//
+// Every operation takes an extra mutex and looks up the goroutine id, so
+// DebugRWMutex is much slower than RWMutex and is strictly a debugging
+// aid. It must be created with NewDebugRWMutex.
+type DebugRWMutex struct {
+	rw RWMutex
+
+	// 保护下面的字段
+	mu      Mutex
+	writer  int64         // 持有写锁的goroutine，0表示没有
+	readers map[int64]int // 每个goroutine持有的读锁个数
+	warn    func(msg string)
+}
+
+// NewDebugRWMutex returns an unlocked DebugRWMutex that reports problems
+// to warn. If warn is nil, problems are written with log.Print.
+func NewDebugRWMutex(warn func(msg string)) *DebugRWMutex {
+	if warn == nil {
+		warn = func(msg string) { log.Print(msg) }
+	}
+	return &DebugRWMutex{
+		readers: make(map[int64]int),
+		warn:    warn,
+	}
+}
+
+// Lock locks m for writing and records the calling goroutine as owner.
+func (m *DebugRWMutex) Lock() {
+	m.rw.Lock()
+	id := goid()
+	m.mu.Lock()
+	m.writer = id
+	m.mu.Unlock()
+}
+
+// Unlock unlocks m for writing, warning if the calling goroutine is not
+// the one that locked it.
+func (m *DebugRWMutex) Unlock() {
+	id := goid()
+	m.mu.Lock()
+	owner := m.writer
+	m.writer = 0
+	m.mu.Unlock()
+	if owner == 0 {
+		m.warn(fmt.Sprintf("sync: DebugRWMutex Unlock of unlocked mutex on goroutine %d", id))
+		return
+	}
+	if owner != id {
+		m.warn(fmt.Sprintf("sync: DebugRWMutex locked by goroutine %d but unlocked by goroutine %d", owner, id))
+	}
+	m.rw.Unlock()
+}
+
+// RLock locks m for reading and records a read token for the calling
+// goroutine.
+func (m *DebugRWMutex) RLock() {
+	m.rw.RLock()
+	id := goid()
+	m.mu.Lock()
+	m.readers[id]++
+	m.mu.Unlock()
+}
+
+// RUnlock undoes a single RLock call made by the calling goroutine. If
+// the calling goroutine holds no read lock, RUnlock only warns.
+func (m *DebugRWMutex) RUnlock() {
+	id := goid()
+	m.mu.Lock()
+	if m.readers[id] > 0 {
+		m.release(id)
+		m.mu.Unlock()
+		m.rw.RUnlock()
+		return
+	}
+	others := len(m.readers)
+	m.mu.Unlock()
+	// 不能替别的goroutine释放读锁，否则它还在读的时候写者就能拿到锁
+	if others == 0 {
+		m.warn(fmt.Sprintf("sync: DebugRWMutex RUnlock without outstanding RLock on goroutine %d (double RUnlock?)", id))
+		return
+	}
+	m.warn(fmt.Sprintf("sync: DebugRWMutex RUnlock on goroutine %d which holds no read lock (double RUnlock, or a read lock taken by another goroutine?)", id))
+}
+
+// release drops one read token of goroutine id. m.mu must be held.
+func (m *DebugRWMutex) release(id int64) {
+	if m.readers[id]--; m.readers[id] == 0 {
+		delete(m.readers, id)
+	}
+}
@@ -0,0 +1,150 @@
+package sync
+
+import (
+	"strings"
+	"testing"
+)
+
+// warnings collects the messages a DebugRWMutex reports.
+type warnings struct {
+	mu   Mutex
+	msgs []string
+}
+
+func (w *warnings) warn(msg string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.msgs = append(w.msgs, msg)
+}
+
+func (w *warnings) take() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	msgs := w.msgs
+	w.msgs = nil
+	return msgs
+}
+
+// expectWarning fails unless exactly one warning containing substr was
+// reported since the last call.
+func (w *warnings) expectWarning(t *testing.T, substr string) {
+	t.Helper()
+	msgs := w.take()
+	if len(msgs) != 1 || !strings.Contains(msgs[0], substr) {
+		t.Fatalf("warnings = %q, want one containing %q", msgs, substr)
+	}
+}
+
+func (w *warnings) expectNone(t *testing.T) {
+	t.Helper()
+	if msgs := w.take(); len(msgs) != 0 {
+		t.Fatalf("unexpected warnings %q", msgs)
+	}
+}
+
+// onGoroutine runs f on a new goroutine and waits for it.
+func onGoroutine(f func()) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f()
+	}()
+	<-done
+}
+
+func TestDebugRWMutexCorrectUse(t *testing.T) {
+	var w warnings
+	m := NewDebugRWMutex(w.warn)
+	m.Lock()
+	m.Unlock()
+	m.RLock()
+	m.RLock()
+	m.RUnlock()
+	m.RUnlock()
+	w.expectNone(t)
+	if !m.rw.TryLock() {
+		t.Fatal("lock still held after balanced calls")
+	}
+}
+
+func TestDebugRWMutexUnlockOtherGoroutine(t *testing.T) {
+	var w warnings
+	m := NewDebugRWMutex(w.warn)
+	m.Lock()
+	onGoroutine(m.Unlock)
+	w.expectWarning(t, "unlocked by goroutine")
+	// The lock is still released, as RWMutex would do.
+	if !m.rw.TryLock() {
+		t.Fatal("write lock not released by the other goroutine")
+	}
+}
+
+func TestDebugRWMutexUnlockUnlocked(t *testing.T) {
+	var w warnings
+	m := NewDebugRWMutex(w.warn)
+	m.Unlock()
+	w.expectWarning(t, "Unlock of unlocked mutex")
+}
+
+func TestDebugRWMutexDoubleRUnlock(t *testing.T) {
+	var w warnings
+	m := NewDebugRWMutex(w.warn)
+	m.RLock()
+	m.RUnlock()
+	m.RUnlock()
+	w.expectWarning(t, "double RUnlock")
+	if !m.rw.TryLock() {
+		t.Fatal("lock corrupted by the ignored RUnlock")
+	}
+}
+
+// A double RUnlock must be detected, and ignored, even while another
+// goroutine holds a read lock; releasing on its behalf would let a writer
+// in while it is still reading.
+func TestDebugRWMutexDoubleRUnlockOtherReader(t *testing.T) {
+	var w warnings
+	m := NewDebugRWMutex(w.warn)
+	release := make(chan struct{})
+	held := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.RLock()
+		close(held)
+		<-release
+		m.RUnlock()
+	}()
+	<-held
+
+	m.RLock()
+	m.RUnlock()
+	m.RUnlock()
+	w.expectWarning(t, "holds no read lock")
+	if m.rw.TryLock() {
+		t.Fatal("writer got the lock while another goroutine holds a read lock")
+	}
+
+	close(release)
+	<-done
+	w.expectNone(t)
+	if !m.rw.TryLock() {
+		t.Fatal("lock still held after the reader released it")
+	}
+}
+
+func TestDebugRWMutexRUnlockOtherGoroutine(t *testing.T) {
+	var w warnings
+	m := NewDebugRWMutex(w.warn)
+	m.RLock()
+	onGoroutine(m.RUnlock)
+	w.expectWarning(t, "holds no read lock")
+	// The owner's read lock is still held and can be released normally.
+	if m.rw.TryLock() {
+		t.Fatal("foreign RUnlock released the read lock")
+	}
+	m.RUnlock()
+	w.expectNone(t)
+	if !m.rw.TryLock() {
+		t.Fatal("lock still held after the owner's RUnlock")
+	}
+}
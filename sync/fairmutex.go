@@ -0,0 +1,64 @@
+package sync
+
+// A FairMutex is a mutual exclusion lock that grants the lock strictly in
+// the order Lock was called.
+//
+// Mutex allows a newly arriving goroutine to barge ahead of waiters and
+// only falls back to FIFO in starvation mode; FairMutex always queues, and
+// Unlock hands ownership directly to the longest waiter. This bounds tail
+// latency at the cost of throughput, since every contended hand-off needs
+// the woken goroutine to be scheduled before anyone can make progress.
+//
+// The zero value for a FairMutex is an unlocked mutex.
+// A FairMutex must not be copied after first use.
+type FairMutex struct {
+	mu      Mutex
+	locked  bool
+	waiters []chan struct{}
+}
+
+// Lock locks m. If the lock is already in use, the calling goroutine
+// blocks behind all goroutines that called Lock earlier.
+func (m *FairMutex) Lock() {
+	m.mu.Lock()
+	if !m.locked {
+		m.locked = true
+		m.mu.Unlock()
+		return
+	}
+	ch := make(chan struct{})
+	m.waiters = append(m.waiters, ch)
+	m.mu.Unlock()
+	// Unlock关闭ch时锁已经直接交给了我们，locked保持为true
+	<-ch
+}
+
+// TryLock tries to lock m and reports whether it succeeded. It fails if
+// the lock is held, even if there are no queued waiters.
+func (m *FairMutex) TryLock() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.locked {
+		return false
+	}
+	m.locked = true
+	return true
+}
+
+// Unlock unlocks m, handing the lock to the first queued waiter if there
+// is one. It is a run-time error if m is not locked on entry to Unlock.
+func (m *FairMutex) Unlock() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.locked {
+		panic("sync: unlock of unlocked FairMutex")
+	}
+	if len(m.waiters) == 0 {
+		m.locked = false
+		return
+	}
+	ch := m.waiters[0]
+	m.waiters[0] = nil
+	m.waiters = m.waiters[1:]
+	close(ch)
+}
@@ -0,0 +1,119 @@
+package sync
+
+import (
+	"slices"
+	"testing"
+)
+
+// queuedOf reports how many goroutines are waiting in m.Lock.
+func queuedOf(m *FairMutex) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.waiters)
+}
+
+func TestFairMutexFIFO(t *testing.T) {
+	const n = 10
+	var m FairMutex
+	m.Lock()
+
+	order := make(chan int, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			m.Lock()
+			order <- i
+			m.Unlock()
+		}()
+		// Start the next goroutine only once this one is queued, so the
+		// enqueue order is known exactly.
+		waitFor(t, "goroutine to queue", func() bool { return queuedOf(&m) == i+1 })
+	}
+	m.Unlock()
+
+	got := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		got = append(got, <-order)
+	}
+	want := make([]int, n)
+	for i := range want {
+		want[i] = i
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("acquisition order = %v, want %v", got, want)
+	}
+}
+
+func TestFairMutexTryLock(t *testing.T) {
+	var m FairMutex
+	if !m.TryLock() {
+		t.Fatal("TryLock on unlocked FairMutex failed")
+	}
+	if m.TryLock() {
+		t.Fatal("TryLock on locked FairMutex succeeded")
+	}
+	m.Unlock()
+
+	// A TryLock must not barge ahead of a handed-off waiter.
+	m.Lock()
+	acquired := make(chan struct{})
+	go func() {
+		m.Lock()
+		close(acquired)
+	}()
+	waitFor(t, "waiter to queue", func() bool { return queuedOf(&m) == 1 })
+	m.Unlock()
+	if m.TryLock() {
+		t.Fatal("TryLock took the lock from a queued waiter")
+	}
+	<-acquired
+	m.Unlock()
+}
+
+func TestFairMutexUnlockUnlocked(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Unlock of unlocked FairMutex did not panic")
+		}
+	}()
+	var m FairMutex
+	m.Unlock()
+}
+
+func TestFairMutexExclusion(t *testing.T) {
+	const (
+		goroutines = 8
+		iterations = 1000
+	)
+	var m FairMutex
+	var wg WaitGroup
+	counter := 0
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < iterations; j++ {
+				m.Lock()
+				counter++
+				m.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if counter != goroutines*iterations {
+		t.Fatalf("counter = %d, want %d", counter, goroutines*iterations)
+	}
+}
+
+func benchmarkLocker(b *testing.B, l Locker) {
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Lock()
+			l.Unlock()
+		}
+	})
+}
+
+// BenchmarkFairMutex and BenchmarkMutex compare contended throughput; Mutex
+// wraps the standard library's sync.Mutex.
+func BenchmarkFairMutex(b *testing.B) { benchmarkLocker(b, &FairMutex{}) }
+func BenchmarkMutex(b *testing.B)     { benchmarkLocker(b, &Mutex{}) }
@@ -0,0 +1,22 @@
+package sync
+
+import "runtime"
+
+// goid returns the id of the calling goroutine, parsed from the header
+// line of runtime.Stack ("goroutine 18 [running]:").
+//
+// It is slow and only meant for the types in this package that need to
+// tell goroutines apart, never for the regular lock paths.
+func goid() int64 {
+	var buf [64]byte
+	n := runtime.Stack(buf[:], false)
+	const prefix = "goroutine "
+	var id int64
+	for _, c := range buf[len(prefix):n] {
+		if c < '0' || c > '9' {
+			break
+		}
+		id = id*10 + int64(c-'0')
+	}
+	return id
+}
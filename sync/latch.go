@@ -0,0 +1,65 @@
+package sync
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// A CountDownLatch lets goroutines wait until a fixed number of events
+// have happened. Unlike WaitGroup, the goroutines counting down and the
+// ones waiting need not know about each other, and waiting can be
+// abandoned through a context.
+type CountDownLatch struct {
+	count atomic.Int64
+	done  chan struct{}
+}
+
+// NewCountDownLatch returns a latch that opens after n calls to
+// CountDown. If n <= 0 the latch is already open.
+func NewCountDownLatch(n int) *CountDownLatch {
+	l := &CountDownLatch{done: make(chan struct{})}
+	l.count.Store(int64(n))
+	if n <= 0 {
+		close(l.done)
+	}
+	return l
+}
+
+// CountDown decrements the count, opening the latch when it reaches zero.
+// Calls after the latch has opened are no-ops.
+func (l *CountDownLatch) CountDown() {
+	for {
+		n := l.count.Load()
+		if n <= 0 {
+			return
+		}
+		if l.count.CompareAndSwap(n, n-1) {
+			// 只有把计数从1减到0的那一次负责关闭done
+			if n == 1 {
+				close(l.done)
+			}
+			return
+		}
+	}
+}
+
+// Count returns the number of CountDown calls still needed.
+func (l *CountDownLatch) Count() int {
+	return int(max(l.count.Load(), 0))
+}
+
+// Wait blocks until the latch opens or ctx is done, in which case it
+// returns ctx.Err().
+func (l *CountDownLatch) Wait(ctx context.Context) error {
+	select {
+	case <-l.done:
+		return nil
+	default:
+	}
+	select {
+	case <-l.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,59 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCountDownLatch(t *testing.T) {
+	const n = 3
+	l := NewCountDownLatch(n)
+	opened := make(chan error, 1)
+	go func() { opened <- l.Wait(context.Background()) }()
+
+	for i := 0; i < n; i++ {
+		select {
+		case err := <-opened:
+			t.Fatalf("Wait returned %v after %d of %d CountDowns", err, i, n)
+		case <-time.After(10 * time.Millisecond):
+		}
+		if c := l.Count(); c != n-i {
+			t.Fatalf("Count = %d, want %d", c, n-i)
+		}
+		l.CountDown()
+	}
+	select {
+	case err := <-opened:
+		if err != nil {
+			t.Fatalf("Wait = %v, want nil", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Wait not released after the last CountDown")
+	}
+
+	// Counting down past zero is a no-op.
+	l.CountDown()
+	if c := l.Count(); c != 0 {
+		t.Fatalf("Count after extra CountDown = %d, want 0", c)
+	}
+}
+
+func TestCountDownLatchDeadline(t *testing.T) {
+	l := NewCountDownLatch(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait = %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestCountDownLatchZero(t *testing.T) {
+	// An already open latch returns even with a canceled context.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := NewCountDownLatch(0).Wait(ctx); err != nil {
+		t.Fatalf("Wait on an open latch = %v, want nil", err)
+	}
+}
@@ -0,0 +1,66 @@
+package sync
+
+import (
+	"cmp"
+	"slices"
+	"unsafe"
+)
+
+// An Unlocker releases a set of RWMutexes acquired together by LockAll or
+// RLockAll.
+type Unlocker struct {
+	mus  []*RWMutex
+	read bool
+}
+
+// Unlock releases the locks in the reverse of the order they were
+// acquired. It must be called exactly once.
+func (u Unlocker) Unlock() {
+	for i := len(u.mus) - 1; i >= 0; i-- {
+		if u.read {
+			u.mus[i].RUnlock()
+		} else {
+			u.mus[i].Unlock()
+		}
+	}
+}
+
+// LockAll locks every mutex in mus for writing and returns an Unlocker
+// that releases them.
+//
+// The mutexes are always locked in order of their address, whatever the
+// order of the arguments, so goroutines that lock overlapping sets through
+// LockAll cannot deadlock with each other. Nil and duplicate entries are
+// ignored.
+func LockAll(mus ...*RWMutex) Unlocker {
+	sorted := sortByAddr(mus)
+	for _, mu := range sorted {
+		mu.Lock()
+	}
+	return Unlocker{mus: sorted}
+}
+
+// RLockAll locks every mutex in mus for reading and returns an Unlocker
+// that releases them. See LockAll for the ordering guarantee.
+func RLockAll(mus ...*RWMutex) Unlocker {
+	sorted := sortByAddr(mus)
+	for _, mu := range sorted {
+		mu.RLock()
+	}
+	return Unlocker{mus: sorted, read: true}
+}
+
+// sortByAddr returns a copy of mus sorted by address, without nil or
+// repeated entries. Locking the same mutex twice would deadlock.
+func sortByAddr(mus []*RWMutex) []*RWMutex {
+	sorted := make([]*RWMutex, 0, len(mus))
+	for _, mu := range mus {
+		if mu != nil {
+			sorted = append(sorted, mu)
+		}
+	}
+	slices.SortFunc(sorted, func(a, b *RWMutex) int {
+		return cmp.Compare(uintptr(unsafe.Pointer(a)), uintptr(unsafe.Pointer(b)))
+	})
+	return slices.Compact(sorted)
+}
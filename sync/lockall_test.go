@@ -0,0 +1,56 @@
+package sync
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLockAllOrdering(t *testing.T) {
+	var a, b, c RWMutex
+	const iterations = 1000
+	done := make(chan struct{})
+	// Opposite argument orders would deadlock quickly with plain Lock calls.
+	for _, set := range [][]*RWMutex{{&a, &b, &c}, {&c, &b, &a}} {
+		go func() {
+			for i := 0; i < iterations; i++ {
+				LockAll(set...).Unlock()
+				RLockAll(set...).Unlock()
+			}
+			done <- struct{}{}
+		}()
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("LockAll deadlocked")
+		}
+	}
+}
+
+func TestLockAllUnlocker(t *testing.T) {
+	var a, b RWMutex
+	u := LockAll(&b, nil, &a, &b)
+	if a.TryRLock() || b.TryRLock() {
+		t.Fatal("RLock succeeded while LockAll held the mutexes")
+	}
+	u.Unlock()
+	if !a.TryLock() || !b.TryLock() {
+		t.Fatal("Unlocker did not release every mutex")
+	}
+	a.Unlock()
+	b.Unlock()
+
+	u = RLockAll(&a, &b, &a)
+	if !a.TryRLock() {
+		t.Fatal("RLockAll excluded other readers")
+	}
+	a.RUnlock()
+	if a.TryLock() || b.TryLock() {
+		t.Fatal("Lock succeeded while RLockAll held the mutexes")
+	}
+	u.Unlock()
+	if !a.TryLock() || !b.TryLock() {
+		t.Fatal("Unlocker did not release every read lock")
+	}
+}
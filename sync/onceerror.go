@@ -0,0 +1,68 @@
+package sync
+
+import "sync/atomic"
+
+// OnceError is like Once for initialization functions that can fail.
+// Do calls fn at most once and every call, including the first, returns
+// the error fn returned.
+//
+// A OnceError must not be copied after first use.
+type OnceError struct {
+	// done表示fn已经执行过，放在最前面和Once一样是为了快路径
+	done atomic.Bool
+	m    Mutex
+	err  error
+}
+
+// Do calls fn if and only if Do is being called for the first time for
+// this OnceError, and returns the error of that single call. Concurrent
+// callers block until the first call has returned.
+//
+// If fn panics, Do considers it returned; future calls of Do return
+// without calling fn and report a nil error.
+func (o *OnceError) Do(fn func() error) error {
+	if o.done.Load() {
+		return o.err
+	}
+	return o.doSlow(fn)
+}
+
+func (o *OnceError) doSlow(fn func() error) error {
+	o.m.Lock()
+	defer o.m.Unlock()
+	if !o.done.Load() {
+		defer o.done.Store(true)
+		o.err = fn()
+	}
+	return o.err
+}
+
+// OnceErrorRetry is like OnceError, except that a failed call does not
+// count: Do keeps calling fn on later calls until fn succeeds once, after
+// which it never calls fn again.
+//
+// A OnceErrorRetry must not be copied after first use.
+type OnceErrorRetry struct {
+	done atomic.Bool
+	m    Mutex
+}
+
+// Do calls fn unless a previous call of fn has already succeeded, and
+// returns the error of this call of fn, or nil if fn was not called.
+// Concurrent callers are serialized, so fn never runs concurrently with
+// itself.
+func (o *OnceErrorRetry) Do(fn func() error) error {
+	if o.done.Load() {
+		return nil
+	}
+	o.m.Lock()
+	defer o.m.Unlock()
+	if o.done.Load() {
+		return nil
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	o.done.Store(true)
+	return nil
+}
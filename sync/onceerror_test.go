@@ -0,0 +1,87 @@
+package sync
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestOnceErrorSuccess(t *testing.T) {
+	var o OnceError
+	var calls atomic.Int32
+	done := make(chan error)
+	for i := 0; i < 10; i++ {
+		go func() {
+			done <- o.Do(func() error {
+				calls.Add(1)
+				return nil
+			})
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		if err := <-done; err != nil {
+			t.Fatalf("Do = %v, want nil", err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("fn called %d times, want 1", n)
+	}
+}
+
+func TestOnceErrorCachesError(t *testing.T) {
+	var o OnceError
+	errInit := errors.New("init failed")
+	calls := 0
+	fn := func() error {
+		calls++
+		return errInit
+	}
+	for i := 0; i < 3; i++ {
+		if err := o.Do(fn); err != errInit {
+			t.Fatalf("Do call %d = %v, want %v", i, err, errInit)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("fn called %d times, want 1", calls)
+	}
+}
+
+func TestOnceErrorPanic(t *testing.T) {
+	var o OnceError
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("Do did not propagate the panic")
+			}
+		}()
+		o.Do(func() error { panic("init") })
+	}()
+	if err := o.Do(func() error { t.Fatal("fn called after a panic"); return nil }); err != nil {
+		t.Fatalf("Do after panic = %v, want nil", err)
+	}
+}
+
+func TestOnceErrorRetry(t *testing.T) {
+	var o OnceErrorRetry
+	errInit := errors.New("init failed")
+	calls := 0
+	fn := func() error {
+		calls++
+		if calls < 3 {
+			return errInit
+		}
+		return nil
+	}
+	for i := 1; i <= 2; i++ {
+		if err := o.Do(fn); err != errInit {
+			t.Fatalf("Do call %d = %v, want %v", i, err, errInit)
+		}
+	}
+	if err := o.Do(fn); err != nil {
+		t.Fatalf("Do call 3 = %v, want nil", err)
+	}
+	// Once fn has succeeded it is never called again.
+	if err := o.Do(fn); err != nil || calls != 3 {
+		t.Fatalf("Do after success = %v with %d calls, want nil with 3", err, calls)
+	}
+}
@@ -0,0 +1,81 @@
+package sync
+
+// A ReentrantRWMutex is a reader/writer mutual exclusion lock that, unlike
+// RWMutex, allows a goroutine that already holds a read lock to call RLock
+// again. Nested read locks do not touch the underlying RWMutex, so they
+// cannot deadlock against a pending writer; the read lock is only released
+// once the outermost RUnlock has been called.
+//
+// Write locks are not reentrant, and a goroutine holding a read lock must
+// not call Lock.
+//
+// The per-goroutine bookkeeping makes RLock and RUnlock considerably
+// slower than those of RWMutex. It is intended for legacy call graphs that
+// cannot be restructured.
+//
+// The zero value for a ReentrantRWMutex is an unlocked mutex.
+// A ReentrantRWMutex must not be copied after first use.
+type ReentrantRWMutex struct {
+	rw RWMutex
+	// 保护readers
+	mu Mutex
+	// 每个goroutine当前的读锁嵌套深度
+	readers map[int64]int
+}
+
+// RLock locks m for reading. If the calling goroutine already holds a read
+// lock on m, RLock only increases its depth and returns immediately.
+func (m *ReentrantRWMutex) RLock() {
+	id := goid()
+	m.mu.Lock()
+	if m.readers[id] > 0 {
+		// 已经持有读锁，只增加深度，不再去底层RWMutex排队（有写者等待时会死锁）
+		m.readers[id]++
+		m.mu.Unlock()
+		return
+	}
+	m.mu.Unlock()
+
+	m.rw.RLock()
+
+	m.mu.Lock()
+	if m.readers == nil {
+		m.readers = make(map[int64]int)
+	}
+	m.readers[id] = 1
+	m.mu.Unlock()
+}
+
+// RUnlock undoes a single RLock call of the calling goroutine. The
+// underlying read lock is released when the goroutine's depth drops to
+// zero. It is a run-time error if the calling goroutine does not hold a
+// read lock on m.
+func (m *ReentrantRWMutex) RUnlock() {
+	id := goid()
+	m.mu.Lock()
+	depth := m.readers[id]
+	if depth == 0 {
+		m.mu.Unlock()
+		fatal("sync: RUnlock of unlocked ReentrantRWMutex")
+		return
+	}
+	if depth > 1 {
+		m.readers[id] = depth - 1
+		m.mu.Unlock()
+		return
+	}
+	delete(m.readers, id)
+	m.mu.Unlock()
+	m.rw.RUnlock()
+}
+
+// Lock locks m for writing. It blocks until every goroutine has released
+// its outermost read lock.
+func (m *ReentrantRWMutex) Lock() {
+	m.rw.Lock()
+}
+
+// Unlock unlocks m for writing.
+func (m *ReentrantRWMutex) Unlock() {
+	m.rw.Unlock()
+}
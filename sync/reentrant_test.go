@@ -0,0 +1,59 @@
+package sync
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReentrantRWMutexNestedWithPendingWriter(t *testing.T) {
+	var m ReentrantRWMutex
+	m.RLock()
+
+	locked := make(chan struct{})
+	go func() {
+		m.Lock()
+		close(locked)
+	}()
+	waitFor(t, "writer to block", func() bool { return m.rw.readerCount.Load() < 0 })
+
+	// A plain RWMutex would deadlock here: the nested RLock would queue
+	// behind the pending writer, which waits for the outer RLock.
+	m.RLock()
+	m.RLock()
+	m.RUnlock()
+	m.RUnlock()
+
+	select {
+	case <-locked:
+		t.Fatal("writer acquired the lock before the outermost RUnlock")
+	case <-time.After(10 * time.Millisecond):
+	}
+	m.RUnlock()
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatal("writer still blocked after the outermost RUnlock")
+	}
+	m.Unlock()
+}
+
+func TestReentrantRWMutexPerGoroutine(t *testing.T) {
+	var m ReentrantRWMutex
+	m.RLock()
+	// Another goroutine's RUnlock must not release this goroutine's lock.
+	panicked := make(chan bool)
+	go func() {
+		defer func() { panicked <- recover() != nil }()
+		m.RUnlock()
+	}()
+	if !<-panicked {
+		t.Fatal("RUnlock from a goroutine without a read lock did not fail")
+	}
+	if m.rw.TryLock() {
+		t.Fatal("read lock lost after foreign RUnlock")
+	}
+	m.RUnlock()
+	if !m.rw.TryLock() {
+		t.Fatal("lock still held after RUnlock")
+	}
+}
@@ -0,0 +1,74 @@
+package sync
+
+// A RingBuffer is a fixed-capacity FIFO buffer that overwrites its oldest
+// element when a Push finds it full. It is safe for concurrent use;
+// Snapshot and Len only take the read lock.
+type RingBuffer[T any] struct {
+	mu    RWMutex
+	buf   []T
+	head  int // index of the oldest element
+	count int
+}
+
+// NewRingBuffer returns an empty RingBuffer holding at most cap elements.
+// It panics if cap < 1.
+func NewRingBuffer[T any](cap int) *RingBuffer[T] {
+	if cap < 1 {
+		panic("sync: NewRingBuffer with non-positive capacity")
+	}
+	return &RingBuffer[T]{buf: make([]T, cap)}
+}
+
+// Push appends v, overwriting the oldest element if the buffer is full.
+func (r *RingBuffer[T]) Push(v T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.count == len(r.buf) {
+		// 满了覆盖最旧的，head往后移一位
+		r.buf[r.head] = v
+		r.head = (r.head + 1) % len(r.buf)
+		return
+	}
+	r.buf[(r.head+r.count)%len(r.buf)] = v
+	r.count++
+}
+
+// Pop removes and returns the oldest element. The boolean is false if the
+// buffer is empty.
+func (r *RingBuffer[T]) Pop() (T, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var zero T
+	if r.count == 0 {
+		return zero, false
+	}
+	v := r.buf[r.head]
+	// 清掉引用，避免取出的元素因为还留在buf里不能被回收
+	r.buf[r.head] = zero
+	r.head = (r.head + 1) % len(r.buf)
+	r.count--
+	return v, true
+}
+
+// Snapshot returns a copy of the current contents, oldest first.
+func (r *RingBuffer[T]) Snapshot() []T {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]T, r.count)
+	for i := range out {
+		out[i] = r.buf[(r.head+i)%len(r.buf)]
+	}
+	return out
+}
+
+// Len returns the number of elements in the buffer.
+func (r *RingBuffer[T]) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.count
+}
+
+// Cap returns the capacity of the buffer.
+func (r *RingBuffer[T]) Cap() int {
+	return len(r.buf)
+}
@@ -0,0 +1,132 @@
+package sync
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRingBufferWrapAround(t *testing.T) {
+	r := NewRingBuffer[int](3)
+	for i := 1; i <= 3; i++ {
+		r.Push(i)
+	}
+	for want := 1; want <= 2; want++ {
+		if v, ok := r.Pop(); !ok || v != want {
+			t.Fatalf("Pop = %d, %v; want %d, true", v, ok, want)
+		}
+	}
+	// head is now at index 2, so these writes wrap to the start of buf.
+	r.Push(4)
+	r.Push(5)
+	if got, want := r.Snapshot(), []int{3, 4, 5}; !slices.Equal(got, want) {
+		t.Fatalf("Snapshot = %v, want %v", got, want)
+	}
+	for _, want := range []int{3, 4, 5} {
+		if v, ok := r.Pop(); !ok || v != want {
+			t.Fatalf("Pop = %d, %v; want %d, true", v, ok, want)
+		}
+	}
+	if v, ok := r.Pop(); ok {
+		t.Fatalf("Pop on empty buffer = %d, true", v)
+	}
+	if n := r.Len(); n != 0 {
+		t.Fatalf("Len = %d, want 0", n)
+	}
+}
+
+func TestRingBufferOverwrite(t *testing.T) {
+	tests := []struct {
+		cap    int
+		pushes int
+		want   []int
+	}{
+		{cap: 1, pushes: 3, want: []int{3}},
+		{cap: 3, pushes: 2, want: []int{1, 2}},
+		{cap: 3, pushes: 3, want: []int{1, 2, 3}},
+		{cap: 3, pushes: 4, want: []int{2, 3, 4}},
+		{cap: 3, pushes: 10, want: []int{8, 9, 10}},
+	}
+	for _, tt := range tests {
+		r := NewRingBuffer[int](tt.cap)
+		for i := 1; i <= tt.pushes; i++ {
+			r.Push(i)
+		}
+		if got := r.Snapshot(); !slices.Equal(got, tt.want) {
+			t.Errorf("cap %d, %d pushes: Snapshot = %v, want %v", tt.cap, tt.pushes, got, tt.want)
+		}
+		if r.Len() != len(tt.want) || r.Cap() != tt.cap {
+			t.Errorf("cap %d, %d pushes: Len, Cap = %d, %d; want %d, %d",
+				tt.cap, tt.pushes, r.Len(), r.Cap(), len(tt.want), tt.cap)
+		}
+	}
+}
+
+func TestRingBufferSnapshotIsCopy(t *testing.T) {
+	r := NewRingBuffer[int](2)
+	r.Push(1)
+	s := r.Snapshot()
+	s[0] = 42
+	if v, _ := r.Pop(); v != 1 {
+		t.Fatalf("modifying a Snapshot changed the buffer: Pop = %d", v)
+	}
+}
+
+func TestNewRingBufferPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("NewRingBuffer(0) did not panic")
+		}
+	}()
+	NewRingBuffer[int](0)
+}
+
+// Run with -race: Snapshot must never see a torn buffer while Push runs.
+func TestRingBufferConcurrentPushSnapshot(t *testing.T) {
+	const (
+		capacity = 8
+		pushes   = 10000
+		readers  = 4
+	)
+	r := NewRingBuffer[int](capacity)
+	var wg WaitGroup
+	done := make(chan struct{})
+	errs := make(chan string, readers)
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				// With a single pusher of 1, 2, 3, ... every snapshot is a
+				// run of consecutive values no longer than the capacity.
+				s := r.Snapshot()
+				if len(s) > capacity {
+					errs <- "snapshot longer than capacity"
+					return
+				}
+				for j := 1; j < len(s); j++ {
+					if s[j] != s[j-1]+1 {
+						errs <- "snapshot not consecutive"
+						return
+					}
+				}
+			}
+		}()
+	}
+	for i := 1; i <= pushes; i++ {
+		r.Push(i)
+	}
+	close(done)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+	if got, want := r.Snapshot(), []int{9993, 9994, 9995, 9996, 9997, 9998, 9999, 10000}; !slices.Equal(got, want) {
+		t.Fatalf("final Snapshot = %v, want %v", got, want)
+	}
+}
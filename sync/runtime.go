@@ -0,0 +1,73 @@
+package sync
+
+import (
+	gosync "sync"
+	"sync/atomic"
+	"time"
+)
+
+// 标准库里下面这些由runtime和mutex.go提供，这个目录只复制了RWMutex相关的文件。
+// 用标准库的sync补上，让这个包不放进GOROOT也能编译、跑测试
+
+// A Locker represents an object that can be locked and unlocked.
+type Locker = gosync.Locker
+
+// A WaitGroup waits for a collection of goroutines to finish.
+type WaitGroup = gosync.WaitGroup
+
+// Once is an object that will perform exactly one action.
+type Once = gosync.Once
+
+// A Mutex is a mutual exclusion lock. It wraps the standard library Mutex;
+// state is only kept so the copied race annotations still compile.
+type Mutex struct {
+	state int32
+	mu    gosync.Mutex
+}
+
+func (m *Mutex) Lock()         { m.mu.Lock() }
+func (m *Mutex) Unlock()       { m.mu.Unlock() }
+func (m *Mutex) TryLock() bool { return m.mu.TryLock() }
+
+// sema 所有信号量共用一把锁和一个条件变量，释放时唤醒全部等待者各自检查
+// 比runtime的实现慢，也不保证公平，但语义一样：计数为0时阻塞，大于0时减一返回
+var sema = struct {
+	mu   gosync.Mutex
+	cond *gosync.Cond
+}{}
+
+func init() {
+	sema.cond = gosync.NewCond(&sema.mu)
+}
+
+func semacquire(s *uint32) {
+	sema.mu.Lock()
+	for atomic.LoadUint32(s) == 0 {
+		sema.cond.Wait()
+	}
+	atomic.AddUint32(s, ^uint32(0))
+	sema.mu.Unlock()
+}
+
+func runtime_SemacquireRWMutexR(s *uint32, lifo bool, skipframes int) { semacquire(s) }
+
+func runtime_SemacquireRWMutex(s *uint32, lifo bool, skipframes int) { semacquire(s) }
+
+func runtime_Semrelease(s *uint32, handoff bool, skipframes int) {
+	sema.mu.Lock()
+	atomic.AddUint32(s, 1)
+	sema.cond.Broadcast()
+	sema.mu.Unlock()
+}
+
+// fatal 标准库里是不能recover的throw，这里用panic代替，测试可以检查
+func fatal(s string) {
+	panic(s)
+}
+
+// processStart runtime_nanotime的起点，只用来算时间差
+var processStart = time.Now()
+
+func runtime_nanotime() int64 {
+	return int64(time.Since(processStart))
+}
@@ -5,7 +5,7 @@
 package sync
 
 import (
-	"internal/race"
+	"gostudy/sync/internal/race"
 	"sync/atomic"
 	"unsafe"
 )
@@ -47,6 +47,13 @@ type RWMutex struct {
     readerCount atomic.Int32 // number of pending readers
 	// 获取写锁时需要等待的读锁释放数量
     readerWait  atomic.Int32 // number of departing readers
+    // 快慢路径的RLock次数，只有rwmutexstats构建标签下才有内容，否则是空结构体
+    readPath    readPathCounters
+    // 写者是否已经持有锁，只有rwmutexdebug构建标签下才有内容，给AssertNoActiveWriter用
+    // 空结构体不能放在最后一个字段，否则编译器会为它补齐，让不带标签的RWMutex变大
+    writer      writerFlag
+    // 只有NewInstrumentedRWMutex创建的锁才不为nil，零值锁不做任何统计
+    stats       *rwmutexStats
 }
 
 //支持最多2^30个读
@@ -83,9 +90,11 @@ func (rw *RWMutex) RLock() {
 
     // 通过readerCount的正负判断读锁与写锁互斥,
     // 如果有写锁存在就挂起读锁的goroutine,多个读锁可以并行
-	if rw.readerCount.Add(1) < 0 {
-		// A writer is pending, wait for it.
-		runtime_SemacquireRWMutexR(&rw.readerSem, false, 0)
+	// 需要统计时连加一也交给rLockSlow，零值锁的RLock保持能被内联
+	if countReadPath || rw.stats != nil || rw.readerCount.Add(1) < 0 {
+		// A writer is pending, or the acquisition must be counted.
+		// Outlined slow-path to allow the fast-path to be inlined
+		rw.rLockSlow()
 	}
 	if race.Enabled {
 		race.Enable()
@@ -93,6 +102,34 @@ func (rw *RWMutex) RLock() {
 	}
 }
 
+// rLockSlow is the outlined part of RLock. For a plain RWMutex, RLock has
+// already counted the reader and found a writer pending. When RLock calls
+// are being counted it has not touched readerCount yet, and rLockSlow does
+// the whole acquisition while updating the counters.
+func (rw *RWMutex) rLockSlow() {
+	if !countReadPath && rw.stats == nil {
+		runtime_SemacquireRWMutexR(&rw.readerSem, false, 0)
+		return
+	}
+	if rw.readerCount.Add(1) >= 0 {
+		rw.readPath.fast()
+		if rw.stats != nil {
+			rw.stats.acquiredReads.Add(1)
+		}
+		return
+	}
+	rw.readPath.slow()
+	var start int64
+	if rw.stats != nil {
+		start = runtime_nanotime()
+	}
+	runtime_SemacquireRWMutexR(&rw.readerSem, false, 0)
+	if rw.stats != nil {
+		rw.stats.blocked(&rw.stats.blockedReads, start)
+		rw.stats.acquiredReads.Add(1)
+	}
+}
+
 // TryRLock tries to lock rw for reading and reports whether it succeeded.
 //
 // Note that while correct uses of TryRLock do exist, they are rare,
@@ -112,6 +149,9 @@ func (rw *RWMutex) TryRLock() bool {
 			return false
 		}
 		if rw.readerCount.CompareAndSwap(c, c+1) {
+			if rw.stats != nil {
+				rw.stats.acquiredReads.Add(1)
+			}
 			if race.Enabled {
 				race.Enable()
 				race.Acquire(unsafe.Pointer(&rw.readerSem))
@@ -177,9 +217,18 @@ func (rw *RWMutex) Lock() {
 		_ = rw.w.state
 		race.Disable()
 	}
+	// 统计时从排队等其他写者开始计时，被其他写者挡住的时间也算写者的等待
+	var start int64
+	blocked := false
+	if rw.stats != nil {
+		start = runtime_nanotime()
+		blocked = !rw.w.TryLock()
+	}
 	// First, resolve competition with other writers.
     //获得互斥锁，用来与其他goroutine互斥
-	rw.w.Lock()
+	if rw.stats == nil || blocked {
+		rw.w.Lock()
+	}
 	// Announce to readers there is a pending writer.
     // 告诉其他来获取读锁操作的goroutine，已经有人获取了写锁
     // 此时readerCount应该介于-rwmutexMaxReaders～0之间
@@ -191,7 +240,15 @@ func (rw *RWMutex) Lock() {
     // 如果没有，写加锁成功
 	if r != 0 && rw.readerWait.Add(r) != 0 {
 		runtime_SemacquireRWMutex(&rw.writerSem, false, 0)
+		blocked = true
 	}
+	if rw.stats != nil {
+		if blocked {
+			rw.stats.writerBlocked(start)
+		}
+		rw.stats.acquiredWrites.Add(1)
+	}
+	rw.writer.acquired()
 	if race.Enabled {
 		race.Enable()
 		race.Acquire(unsafe.Pointer(&rw.readerSem))
@@ -222,6 +279,10 @@ func (rw *RWMutex) TryLock() bool {
 		}
 		return false
 	}
+	if rw.stats != nil {
+		rw.stats.acquiredWrites.Add(1)
+	}
+	rw.writer.acquired()
 	if race.Enabled {
 		race.Enable()
 		race.Acquire(unsafe.Pointer(&rw.readerSem))
@@ -244,6 +305,7 @@ func (rw *RWMutex) Unlock() {
 		race.Disable()
 	}
 
+	rw.writer.released()
 	// Announce to readers there is no active writer.
     // 还原加锁时减去的那一部分readerCount
 	r := rw.readerCount.Add(rwmutexMaxReaders)
@@ -265,6 +327,40 @@ func (rw *RWMutex) Unlock() {
 	}
 }
 
+// DowngradeToRead atomically converts the write lock held on rw into a
+// read lock. No other writer can acquire rw between the two states;
+// readers that were blocked by the write lock are released and proceed
+// alongside the caller.
+//
+// It is a run-time error if rw is not locked for writing on entry.
+// After DowngradeToRead the caller holds a read lock and must release it
+// with RUnlock; calling Unlock afterwards is a programming error.
+func (rw *RWMutex) DowngradeToRead() {
+	if race.Enabled {
+		_ = rw.w.state
+		race.Release(unsafe.Pointer(&rw.readerSem))
+		race.Disable()
+	}
+
+	rw.writer.released()
+	// 一步完成两件事：撤销写锁时减掉的rwmutexMaxReaders，同时把自己算成一个读者。
+	// 这之后再释放w，新的写者拿到w时一定能看到当前这个读者，没有插队的窗口
+	r := rw.readerCount.Add(rwmutexMaxReaders + 1)
+	if r > rwmutexMaxReaders {
+		race.Enable()
+		fatal("sync: DowngradeToRead of unlocked RWMutex")
+	}
+	// 唤醒持有写锁期间被阻塞的读者，r里包含了自己，所以是r-1个
+	for i := 0; i < int(r)-1; i++ {
+		runtime_Semrelease(&rw.readerSem, false, 0)
+	}
+	// 允许其他写者排队，它们会等包括自己在内的读者全部RUnlock
+	rw.w.Unlock()
+	if race.Enabled {
+		race.Enable()
+	}
+}
+
 // RLocker returns a Locker interface that implements
 // the Lock and Unlock methods by calling rw.RLock and rw.RUnlock.
 func (rw *RWMutex) RLocker() Locker {
@@ -0,0 +1,31 @@
+//go:build rwmutexdebug
+
+package sync
+
+import "sync/atomic"
+
+// writerFlag records whether a writer currently holds the lock. It is only
+// populated with the rwmutexdebug build tag, for AssertNoActiveWriter.
+//
+// readerCount alone cannot tell a holding writer from a pending one: Lock
+// makes it negative before it knows how many readers it has to wait for.
+type writerFlag struct {
+	held atomic.Bool
+}
+
+func (f *writerFlag) acquired() { f.held.Store(true) }
+func (f *writerFlag) released() { f.held.Store(false) }
+
+// AssertNoActiveWriter panics if a writer currently holds rw. It is meant
+// for test assertions on read-mostly data, where holding the write lock
+// while readers expect stable state is always a bug. A writer that is
+// still waiting for readers to leave does not count as holding rw.
+//
+// The check is only compiled in with the rwmutexdebug build tag; in other
+// builds AssertNoActiveWriter does nothing. It can only catch violations
+// that are in progress at the moment of the call.
+func (rw *RWMutex) AssertNoActiveWriter() {
+	if rw.writer.held.Load() {
+		panic("sync: RWMutex is held by a writer")
+	}
+}
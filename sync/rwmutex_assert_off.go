@@ -0,0 +1,14 @@
+//go:build !rwmutexdebug
+
+package sync
+
+// writerFlag is empty unless built with the rwmutexdebug build tag, so
+// Lock and Unlock pay nothing for AssertNoActiveWriter.
+type writerFlag struct{}
+
+func (*writerFlag) acquired() {}
+func (*writerFlag) released() {}
+
+// AssertNoActiveWriter does nothing unless built with the rwmutexdebug
+// build tag. See the tagged version for details.
+func (rw *RWMutex) AssertNoActiveWriter() {}
@@ -0,0 +1,65 @@
+//go:build rwmutexdebug
+
+package sync
+
+import "testing"
+
+// assertPanics reports whether AssertNoActiveWriter panicked on rw.
+func assertPanics(rw *RWMutex) (panicked bool) {
+	defer func() {
+		panicked = recover() != nil
+	}()
+	rw.AssertNoActiveWriter()
+	return false
+}
+
+func TestAssertNoActiveWriter(t *testing.T) {
+	var rw RWMutex
+	if assertPanics(&rw) {
+		t.Fatal("panicked on an unlocked RWMutex")
+	}
+
+	rw.RLock()
+	rw.RLock()
+	if assertPanics(&rw) {
+		t.Fatal("panicked while only readers held the lock")
+	}
+
+	// A writer waiting for the readers has not acquired the lock yet.
+	locked := make(chan struct{})
+	release := make(chan struct{})
+	go func() {
+		rw.Lock()
+		close(locked)
+		<-release
+		rw.Unlock()
+	}()
+	waitFor(t, "writer to block", func() bool { return rw.readerWait.Load() == 2 })
+	if assertPanics(&rw) {
+		t.Fatal("panicked while the writer was still waiting for readers")
+	}
+	rw.RUnlock()
+	rw.RUnlock()
+	<-locked
+	if !assertPanics(&rw) {
+		t.Fatal("did not panic while a writer held the lock")
+	}
+	close(release)
+	waitFor(t, "writer to unlock", func() bool { return rw.TryRLock() })
+	rw.RUnlock()
+	if assertPanics(&rw) {
+		t.Fatal("panicked after Unlock")
+	}
+
+	if !rw.TryLock() {
+		t.Fatal("TryLock failed on an unlocked RWMutex")
+	}
+	if !assertPanics(&rw) {
+		t.Fatal("did not panic while TryLock held the lock")
+	}
+	rw.DowngradeToRead()
+	if assertPanics(&rw) {
+		t.Fatal("panicked after DowngradeToRead")
+	}
+	rw.RUnlock()
+}
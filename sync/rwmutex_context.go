@@ -0,0 +1,65 @@
+package sync
+
+import "context"
+
+// LockContext locks rw for writing, like Lock, but gives up and returns
+// ctx.Err() if ctx is done before the lock is acquired.
+//
+// The runtime semaphore cannot be interrupted, so on cancellation the
+// pending acquisition keeps running in the background and the lock is
+// released as soon as it is obtained. Until then the abandoned writer
+// still counts as pending and blocks new readers.
+func (rw *RWMutex) LockContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	// 先走一次不阻塞的快路径，拿到就不用起goroutine
+	if rw.TryLock() {
+		return nil
+	}
+	done := make(chan struct{})
+	go func() {
+		rw.Lock()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		// 后台goroutine迟早会拿到锁，拿到后马上释放，否则锁会被永久占用
+		go func() {
+			<-done
+			rw.Unlock()
+		}()
+		return ctx.Err()
+	}
+}
+
+// RLockContext locks rw for reading, like RLock, but gives up and returns
+// ctx.Err() if ctx is done before the lock is acquired.
+//
+// As with LockContext, an abandoned acquisition completes in the
+// background and is immediately undone with RUnlock.
+func (rw *RWMutex) RLockContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if rw.TryRLock() {
+		return nil
+	}
+	done := make(chan struct{})
+	go func() {
+		rw.RLock()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		go func() {
+			<-done
+			rw.RUnlock()
+		}()
+		return ctx.Err()
+	}
+}
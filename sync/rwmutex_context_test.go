@@ -0,0 +1,77 @@
+package sync
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLockContextDeadline(t *testing.T) {
+	var rw RWMutex
+	rw.Lock()
+
+	for name, lock := range map[string]func(context.Context) error{
+		"LockContext":  rw.LockContext,
+		"RLockContext": rw.RLockContext,
+	} {
+		errc := make(chan error, 1)
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			errc <- lock(ctx)
+		}()
+		select {
+		case err := <-errc:
+			if err != context.DeadlineExceeded {
+				t.Fatalf("%s = %v, want context.DeadlineExceeded", name, err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%s did not return after its deadline", name)
+		}
+	}
+
+	// The abandoned acquisitions release the lock as soon as they get it.
+	rw.Unlock()
+	done := make(chan struct{})
+	go func() {
+		rw.Lock()
+		rw.Unlock()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("lock still held after abandoned LockContext calls")
+	}
+}
+
+func TestLockContextAcquires(t *testing.T) {
+	var rw RWMutex
+	ctx := context.Background()
+	if err := rw.LockContext(ctx); err != nil {
+		t.Fatalf("LockContext = %v", err)
+	}
+	// A reader waiting on the writer gets the lock once it is released.
+	errc := make(chan error, 1)
+	go func() {
+		errc <- rw.RLockContext(ctx)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	rw.Unlock()
+	if err := <-errc; err != nil {
+		t.Fatalf("RLockContext = %v", err)
+	}
+	rw.RUnlock()
+}
+
+func TestLockContextCanceled(t *testing.T) {
+	var rw RWMutex
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := rw.LockContext(ctx); err != context.Canceled {
+		t.Fatalf("LockContext = %v, want context.Canceled", err)
+	}
+	if !rw.TryLock() {
+		t.Fatal("canceled LockContext left rw locked")
+	}
+}
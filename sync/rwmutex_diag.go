@@ -0,0 +1,24 @@
+package sync
+
+// ReaderCount returns the number of goroutines currently holding rw for
+// reading. It is meant for diagnostics such as deadlock debugging and does
+// not change the state of rw.
+//
+// The result is advisory only: it is a best-effort snapshot that may be
+// stale by the time it is returned, and must not be used for
+// synchronization.
+func (rw *RWMutex) ReaderCount() int {
+	r := rw.readerCount.Load()
+	if r >= 0 {
+		// 没有写者，readerCount就是持有读锁的读者数
+		return int(r)
+	}
+	// 有写者在等待或已持有写锁：readerCount里加上的是被阻塞的新读者，
+	// 真正还持有读锁的是写者正在等的那部分，也就是readerWait。
+	// Lock里两次原子操作之间readerWait可能短暂为负，按0处理
+	w := rw.readerWait.Load()
+	if w < 0 {
+		return 0
+	}
+	return int(w)
+}
@@ -0,0 +1,45 @@
+package sync
+
+import "testing"
+
+func TestReaderCount(t *testing.T) {
+	var rw RWMutex
+	for i := 0; i < 3; i++ {
+		rw.RLock()
+	}
+	if n := rw.ReaderCount(); n != 3 {
+		t.Fatalf("ReaderCount = %d, want 3", n)
+	}
+
+	// With a writer pending, new readers queue up behind it and must not
+	// be counted as holding the lock.
+	locked := make(chan struct{})
+	go func() {
+		rw.Lock()
+		close(locked)
+	}()
+	waitFor(t, "writer to block", func() bool { return rw.readerCount.Load() < 0 })
+	rlocked := make(chan struct{})
+	go func() {
+		rw.RLock()
+		close(rlocked)
+	}()
+	waitFor(t, "reader to queue", func() bool { return rw.readerCount.Load() == -rwmutexMaxReaders+4 })
+	if n := rw.ReaderCount(); n != 3 {
+		t.Fatalf("ReaderCount with writer pending = %d, want 3", n)
+	}
+
+	for i := 0; i < 3; i++ {
+		rw.RUnlock()
+	}
+	<-locked
+	if n := rw.ReaderCount(); n != 0 {
+		t.Fatalf("ReaderCount while write-locked = %d, want 0", n)
+	}
+	rw.Unlock()
+	<-rlocked
+	rw.RUnlock()
+	if n := rw.ReaderCount(); n != 0 {
+		t.Fatalf("ReaderCount after release = %d, want 0", n)
+	}
+}
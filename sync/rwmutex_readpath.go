@@ -0,0 +1,29 @@
+//go:build rwmutexstats
+
+package sync
+
+import "sync/atomic"
+
+// countReadPath sends every RLock through rLockSlow so that uncontended
+// acquisitions are counted too.
+const countReadPath = true
+
+// readPathCounters counts how RLock calls acquired the lock. It is only
+// populated with the rwmutexstats build tag, for benchmarking read-mostly
+// workloads.
+type readPathCounters struct {
+	fastReads atomic.Uint64 // RLock calls that did not wait
+	slowReads atomic.Uint64 // RLock calls that waited for a writer
+}
+
+func (c *readPathCounters) fast() { c.fastReads.Add(1) }
+func (c *readPathCounters) slow() { c.slowReads.Add(1) }
+
+// ReadPathStats returns how many RLock calls on rw took the uncontended
+// fast path and how many had to wait for a writer.
+//
+// The counters are only compiled in with the rwmutexstats build tag; in
+// other builds ReadPathStats always returns zeros.
+func (rw *RWMutex) ReadPathStats() (fast, slow uint64) {
+	return rw.readPath.fastReads.Load(), rw.readPath.slowReads.Load()
+}
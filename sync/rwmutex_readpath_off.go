@@ -0,0 +1,17 @@
+//go:build !rwmutexstats
+
+package sync
+
+// countReadPath is false so the check in RLock is compiled out.
+const countReadPath = false
+
+// readPathCounters is empty unless built with the rwmutexstats build tag,
+// so RLock keeps its small, inlinable fast path.
+type readPathCounters struct{}
+
+func (*readPathCounters) fast() {}
+func (*readPathCounters) slow() {}
+
+// ReadPathStats always returns zeros unless built with the rwmutexstats
+// build tag. See the tagged version for details.
+func (rw *RWMutex) ReadPathStats() (fast, slow uint64) { return 0, 0 }
@@ -0,0 +1,78 @@
+//go:build rwmutexstats
+
+package sync
+
+import "testing"
+
+func TestReadPathStatsUncontended(t *testing.T) {
+	const n = 1000
+	var rw RWMutex
+	for i := 0; i < n; i++ {
+		rw.RLock()
+		rw.RUnlock()
+	}
+	if fast, slow := rw.ReadPathStats(); fast != n || slow != 0 {
+		t.Fatalf("ReadPathStats = %d, %d; want %d, 0", fast, slow, n)
+	}
+}
+
+func TestReadPathStatsContended(t *testing.T) {
+	const readers = 5
+	var rw RWMutex
+	rw.RLock()
+	rw.RUnlock()
+
+	rw.Lock()
+	var wg WaitGroup
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rw.RLock()
+			rw.RUnlock()
+		}()
+	}
+	// slow is counted before the reader parks, so once it reaches readers
+	// every goroutine is waiting for the writer.
+	waitFor(t, "readers to block", func() bool {
+		_, slow := rw.ReadPathStats()
+		return slow == readers
+	})
+	rw.Unlock()
+	wg.Wait()
+
+	if fast, slow := rw.ReadPathStats(); fast != 1 || slow != readers {
+		t.Fatalf("ReadPathStats = %d, %d; want 1, %d", fast, slow, readers)
+	}
+}
+
+// BenchmarkReadPathStats runs a read-mostly workload and reports how many
+// RLock calls per operation took each path.
+func BenchmarkReadPathStats(b *testing.B) {
+	for _, bc := range []struct {
+		name        string
+		writeEveryN int // 0 means readers only
+	}{
+		{name: "ReadOnly"},
+		{name: "Write1in100", writeEveryN: 100},
+		{name: "Write1in10", writeEveryN: 10},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			var rw RWMutex
+			b.RunParallel(func(pb *testing.PB) {
+				for i := 1; pb.Next(); i++ {
+					if bc.writeEveryN > 0 && i%bc.writeEveryN == 0 {
+						rw.Lock()
+						rw.Unlock()
+						continue
+					}
+					rw.RLock()
+					rw.RUnlock()
+				}
+			})
+			fast, slow := rw.ReadPathStats()
+			b.ReportMetric(float64(fast)/float64(b.N), "fast/op")
+			b.ReportMetric(float64(slow)/float64(b.N), "slow/op")
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package sync
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// RWMutexStats is a snapshot of the contention counters of an
+// instrumented RWMutex.
+type RWMutexStats struct {
+	AcquiredReads  uint64 // number of completed RLock and successful TryRLock calls
+	AcquiredWrites uint64 // number of completed Lock and successful TryLock calls
+	BlockedReads   uint64 // RLock calls that had to wait for a writer
+	BlockedWrites  uint64 // Lock calls that had to wait for another writer or for readers
+	TotalWaitNanos int64  // time spent blocked in Lock and RLock
+
+	MaxWriterWaitNanos int64 // longest single wait of a Lock call
+}
+
+type rwmutexStats struct {
+	acquiredReads  atomic.Uint64
+	acquiredWrites atomic.Uint64
+	blockedReads   atomic.Uint64
+	blockedWrites  atomic.Uint64
+	totalWaitNanos atomic.Int64
+
+	maxWriterWait   atomic.Int64
+	starveThreshold atomic.Int64
+	starveHandler   atomic.Pointer[func(waited time.Duration)]
+}
+
+// blocked records one slow-path acquisition that started waiting at start.
+func (s *rwmutexStats) blocked(counter *atomic.Uint64, start int64) {
+	counter.Add(1)
+	s.totalWaitNanos.Add(runtime_nanotime() - start)
+}
+
+// writerBlocked records a Lock call that waited for other writers or for
+// readers since start, and reports it to the starvation handler if it
+// waited too long.
+func (s *rwmutexStats) writerBlocked(start int64) {
+	waited := runtime_nanotime() - start
+	s.blockedWrites.Add(1)
+	s.totalWaitNanos.Add(waited)
+	for {
+		prev := s.maxWriterWait.Load()
+		if waited <= prev || s.maxWriterWait.CompareAndSwap(prev, waited) {
+			break
+		}
+	}
+	threshold := s.starveThreshold.Load()
+	if fn := s.starveHandler.Load(); fn != nil && waited > threshold {
+		(*fn)(time.Duration(waited))
+	}
+}
+
+// NewInstrumentedRWMutex returns an unlocked RWMutex that counts how often
+// Lock and RLock block and how long they wait. Wait time is only measured
+// on the slow path, when the goroutine actually has to wait; for Lock that
+// includes queueing behind other writers as well as waiting for readers.
+//
+// The zero value RWMutex carries no counters and pays only a nil check.
+func NewInstrumentedRWMutex() *RWMutex {
+	return &RWMutex{stats: new(rwmutexStats)}
+}
+
+// Stats returns the current counters of rw. For a RWMutex not created by
+// NewInstrumentedRWMutex it returns the zero RWMutexStats.
+//
+// The fields are loaded individually, so the snapshot is not atomic as a
+// whole while other goroutines are using the lock.
+func (rw *RWMutex) Stats() RWMutexStats {
+	s := rw.stats
+	if s == nil {
+		return RWMutexStats{}
+	}
+	return RWMutexStats{
+		AcquiredReads:  s.acquiredReads.Load(),
+		AcquiredWrites: s.acquiredWrites.Load(),
+		BlockedReads:   s.blockedReads.Load(),
+		BlockedWrites:  s.blockedWrites.Load(),
+		TotalWaitNanos: s.totalWaitNanos.Load(),
+
+		MaxWriterWaitNanos: s.maxWriterWait.Load(),
+	}
+}
+
+// SetStarvationHandler arranges for fn to be called whenever a Lock call
+// on rw had to wait longer than threshold, behind other writers or for
+// readers to leave. A nil fn removes the handler.
+//
+// fn is called synchronously by the writer after it has acquired the
+// lock, so it must be quick and must not try to lock rw itself.
+// SetStarvationHandler has no effect on a RWMutex not created by
+// NewInstrumentedRWMutex.
+func (rw *RWMutex) SetStarvationHandler(threshold time.Duration, fn func(waited time.Duration)) {
+	s := rw.stats
+	if s == nil {
+		return
+	}
+	s.starveThreshold.Store(int64(threshold))
+	if fn == nil {
+		s.starveHandler.Store(nil)
+		return
+	}
+	s.starveHandler.Store(&fn)
+}
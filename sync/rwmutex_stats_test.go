@@ -0,0 +1,216 @@
+package sync
+
+import (
+	"testing"
+	"time"
+)
+
+// waitFor polls cond until it holds, failing the test after a second.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestInstrumentedRWMutexBlockedReads(t *testing.T) {
+	const readers = 5
+	rw := NewInstrumentedRWMutex()
+	rw.Lock()
+
+	done := make(chan struct{})
+	for i := 0; i < readers; i++ {
+		go func() {
+			rw.RLock()
+			rw.RUnlock()
+			done <- struct{}{}
+		}()
+	}
+	waitFor(t, "readers to block", func() bool {
+		return rw.readerCount.Load() == -rwmutexMaxReaders+readers
+	})
+	time.Sleep(time.Millisecond)
+	rw.Unlock()
+	for i := 0; i < readers; i++ {
+		<-done
+	}
+
+	s := rw.Stats()
+	if s.BlockedReads != readers || s.AcquiredReads != readers {
+		t.Fatalf("BlockedReads = %d, AcquiredReads = %d, want %d", s.BlockedReads, s.AcquiredReads, readers)
+	}
+	if s.AcquiredWrites != 1 || s.BlockedWrites != 0 {
+		t.Fatalf("AcquiredWrites = %d, BlockedWrites = %d, want 1, 0", s.AcquiredWrites, s.BlockedWrites)
+	}
+	if s.TotalWaitNanos <= 0 {
+		t.Fatalf("TotalWaitNanos = %d, want > 0", s.TotalWaitNanos)
+	}
+}
+
+func TestInstrumentedRWMutexBlockedWrites(t *testing.T) {
+	rw := NewInstrumentedRWMutex()
+	rw.RLock()
+	rw.RLock()
+
+	done := make(chan struct{})
+	go func() {
+		rw.Lock()
+		rw.Unlock()
+		close(done)
+	}()
+	waitFor(t, "writer to block", func() bool { return rw.readerCount.Load() < 0 })
+	rw.RUnlock()
+	rw.RUnlock()
+	<-done
+
+	s := rw.Stats()
+	if s.AcquiredReads != 2 || s.BlockedReads != 0 {
+		t.Fatalf("AcquiredReads = %d, BlockedReads = %d, want 2, 0", s.AcquiredReads, s.BlockedReads)
+	}
+	if s.AcquiredWrites != 1 || s.BlockedWrites != 1 {
+		t.Fatalf("AcquiredWrites = %d, BlockedWrites = %d, want 1, 1", s.AcquiredWrites, s.BlockedWrites)
+	}
+}
+
+func TestInstrumentedRWMutexTryLockCounted(t *testing.T) {
+	rw := NewInstrumentedRWMutex()
+	rw.Lock()
+	rw.Unlock()
+	if !rw.TryLock() {
+		t.Fatal("TryLock on an unlocked RWMutex failed")
+	}
+	// Failed attempts are not acquisitions.
+	if rw.TryLock() || rw.TryRLock() {
+		t.Fatal("Try* succeeded while write-locked")
+	}
+	rw.Unlock()
+
+	rw.RLock()
+	if !rw.TryRLock() {
+		t.Fatal("TryRLock alongside a reader failed")
+	}
+	if rw.TryLock() {
+		t.Fatal("TryLock succeeded while read-locked")
+	}
+	rw.RUnlock()
+	rw.RUnlock()
+
+	s := rw.Stats()
+	if s.AcquiredWrites != 2 || s.AcquiredReads != 2 {
+		t.Fatalf("AcquiredWrites = %d, AcquiredReads = %d, want 2, 2", s.AcquiredWrites, s.AcquiredReads)
+	}
+	if s.BlockedWrites != 0 || s.BlockedReads != 0 {
+		t.Fatalf("BlockedWrites = %d, BlockedReads = %d, want 0, 0", s.BlockedWrites, s.BlockedReads)
+	}
+}
+
+func TestRWMutexStatsZeroValue(t *testing.T) {
+	var rw RWMutex
+	rw.Lock()
+	rw.Unlock()
+	rw.RLock()
+	rw.RUnlock()
+	if s := rw.Stats(); s != (RWMutexStats{}) {
+		t.Fatalf("Stats of a plain RWMutex = %+v, want zero", s)
+	}
+}
+
+func TestStarvationHandler(t *testing.T) {
+	const threshold = 10 * time.Millisecond
+	rw := NewInstrumentedRWMutex()
+	fired := make(chan time.Duration, 1)
+	rw.SetStarvationHandler(threshold, func(waited time.Duration) { fired <- waited })
+
+	// Pin a read lock for 50ms while a writer waits for it.
+	rw.RLock()
+	done := make(chan struct{})
+	go func() {
+		rw.Lock()
+		rw.Unlock()
+		close(done)
+	}()
+	waitFor(t, "writer to block", func() bool { return rw.readerCount.Load() < 0 })
+	time.Sleep(50 * time.Millisecond)
+	rw.RUnlock()
+	<-done
+
+	select {
+	case waited := <-fired:
+		if waited <= threshold {
+			t.Fatalf("handler called with %v, want > %v", waited, threshold)
+		}
+	default:
+		t.Fatal("starvation handler did not fire")
+	}
+	if max := rw.Stats().MaxWriterWaitNanos; max <= int64(threshold) {
+		t.Fatalf("MaxWriterWaitNanos = %d, want > %d", max, int64(threshold))
+	}
+
+	// A writer that does not block does not reach the handler.
+	rw.Lock()
+	rw.Unlock()
+	select {
+	case waited := <-fired:
+		t.Fatalf("handler fired for an uncontended Lock with %v", waited)
+	default:
+	}
+}
+
+// Time a writer spends queued behind another writer counts as well.
+func TestStarvationHandlerBehindWriter(t *testing.T) {
+	const threshold = 10 * time.Millisecond
+	rw := NewInstrumentedRWMutex()
+	fired := make(chan time.Duration, 1)
+	rw.SetStarvationHandler(threshold, func(waited time.Duration) { fired <- waited })
+
+	rw.Lock()
+	started := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		close(started)
+		rw.Lock()
+		rw.Unlock()
+		close(done)
+	}()
+	<-started
+	time.Sleep(50 * time.Millisecond)
+	rw.Unlock()
+	<-done
+
+	select {
+	case waited := <-fired:
+		if waited <= threshold {
+			t.Fatalf("handler called with %v, want > %v", waited, threshold)
+		}
+	default:
+		t.Fatal("starvation handler did not fire for a writer queued behind a writer")
+	}
+	s := rw.Stats()
+	if s.AcquiredWrites != 2 || s.BlockedWrites != 1 {
+		t.Fatalf("AcquiredWrites = %d, BlockedWrites = %d, want 2, 1", s.AcquiredWrites, s.BlockedWrites)
+	}
+	if s.MaxWriterWaitNanos <= int64(threshold) || s.TotalWaitNanos < s.MaxWriterWaitNanos {
+		t.Fatalf("MaxWriterWaitNanos = %d, TotalWaitNanos = %d, want > %d", s.MaxWriterWaitNanos, s.TotalWaitNanos, int64(threshold))
+	}
+}
+
+func TestStarvationHandlerRemoved(t *testing.T) {
+	rw := NewInstrumentedRWMutex()
+	rw.SetStarvationHandler(0, func(time.Duration) { t.Fatal("removed handler called") })
+	rw.SetStarvationHandler(0, nil)
+
+	rw.RLock()
+	done := make(chan struct{})
+	go func() {
+		rw.Lock()
+		rw.Unlock()
+		close(done)
+	}()
+	waitFor(t, "writer to block", func() bool { return rw.readerCount.Load() < 0 })
+	rw.RUnlock()
+	<-done
+}
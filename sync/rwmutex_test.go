@@ -0,0 +1,82 @@
+package sync
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+// Without build tags the debug and read-path fields are empty, and the
+// RWMutex must be no larger than the upstream layout plus the stats pointer.
+// A zero-size last field would be padded and break this.
+func TestRWMutexSize(t *testing.T) {
+	if unsafe.Sizeof(readPathCounters{}) != 0 || unsafe.Sizeof(writerFlag{}) != 0 {
+		t.Skip("build tags add fields to RWMutex")
+	}
+	type baseline struct {
+		w           Mutex
+		writerSem   uint32
+		readerSem   uint32
+		readerCount atomic.Int32
+		readerWait  atomic.Int32
+		stats       *rwmutexStats
+	}
+	if got, want := unsafe.Sizeof(RWMutex{}), unsafe.Sizeof(baseline{}); got != want {
+		t.Fatalf("unsafe.Sizeof(RWMutex{}) = %d, want %d", got, want)
+	}
+}
+
+func TestDowngradeToRead(t *testing.T) {
+	var rw RWMutex
+	rw.Lock()
+	// A reader blocked by the write lock is let in by the downgrade.
+	blocked := make(chan struct{})
+	go func() {
+		rw.RLock()
+		close(blocked)
+	}()
+	waitFor(t, "reader to block", func() bool { return rw.readerCount.Load() == -rwmutexMaxReaders+1 })
+
+	rw.DowngradeToRead()
+	select {
+	case <-blocked:
+	case <-time.After(time.Second):
+		t.Fatal("blocked reader not released by DowngradeToRead")
+	}
+	if !rw.TryRLock() {
+		t.Fatal("RLock after DowngradeToRead did not succeed immediately")
+	}
+
+	locked := make(chan struct{})
+	go func() {
+		rw.Lock()
+		close(locked)
+	}()
+	// Three readers now: the downgraded writer, the released reader and
+	// the TryRLock. The writer has to wait for all of them.
+	for i := 0; i < 3; i++ {
+		select {
+		case <-locked:
+			t.Fatalf("Lock acquired with %d readers still holding rw", 3-i)
+		case <-time.After(10 * time.Millisecond):
+		}
+		rw.RUnlock()
+	}
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatal("Lock not acquired after all readers released")
+	}
+	rw.Unlock()
+}
+
+func TestDowngradeToReadUnlocked(t *testing.T) {
+	var rw RWMutex
+	defer func() {
+		if recover() == nil {
+			t.Fatal("DowngradeToRead of unlocked RWMutex did not fail")
+		}
+	}()
+	rw.DowngradeToRead()
+}
@@ -0,0 +1,54 @@
+package sync
+
+import "time"
+
+// Polling intervals used by TryLockTimeout and TryRLockTimeout. The wait
+// starts short so briefly held locks are picked up quickly and backs off
+// so long waits do not spin.
+const (
+	tryLockMinBackoff = time.Microsecond
+	tryLockMaxBackoff = time.Millisecond
+)
+
+// TryLockTimeout tries to lock rw for writing for up to d and reports
+// whether it succeeded.
+//
+// It polls TryLock rather than sleeping on the semaphore, so a timed-out
+// call leaves no goroutine or pending writer behind. Like TryLock, each
+// attempt that wins the internal mutex but finds active readers releases
+// the mutex again before backing off.
+func (rw *RWMutex) TryLockTimeout(d time.Duration) bool {
+	return tryUntil(d, rw.TryLock)
+}
+
+// TryRLockTimeout tries to lock rw for reading for up to d and reports
+// whether it succeeded. See TryLockTimeout.
+func (rw *RWMutex) TryRLockTimeout(d time.Duration) bool {
+	return tryUntil(d, rw.TryRLock)
+}
+
+// tryUntil calls try with exponential backoff until it succeeds or d
+// has elapsed. try is always called at least once.
+func tryUntil(d time.Duration, try func() bool) bool {
+	if try() {
+		return true
+	}
+	deadline := time.Now().Add(d)
+	backoff := tryLockMinBackoff
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return false
+		}
+		if backoff > remaining {
+			backoff = remaining
+		}
+		time.Sleep(backoff)
+		if try() {
+			return true
+		}
+		if backoff < tryLockMaxBackoff {
+			backoff *= 2
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package sync
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestTryLockTimeout(t *testing.T) {
+	var rw RWMutex
+	start := time.Now()
+	if !rw.TryLockTimeout(time.Second) {
+		t.Fatal("uncontended TryLockTimeout = false")
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Fatalf("uncontended TryLockTimeout took %v", elapsed)
+	}
+
+	goroutines := runtime.NumGoroutine()
+	start = time.Now()
+	if rw.TryLockTimeout(10 * time.Millisecond) {
+		t.Fatal("TryLockTimeout on a write-locked RWMutex = true")
+	}
+	if rw.TryRLockTimeout(10 * time.Millisecond) {
+		t.Fatal("TryRLockTimeout on a write-locked RWMutex = true")
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Fatalf("timed-out calls returned after %v", elapsed)
+	}
+	if n := runtime.NumGoroutine(); n > goroutines {
+		t.Fatalf("goroutines = %d after timeouts, want <= %d", n, goroutines)
+	}
+	rw.Unlock()
+}
+
+func TestTryLockTimeoutReleasesMutex(t *testing.T) {
+	var rw RWMutex
+	rw.RLock()
+	// Every attempt wins w but finds a reader and must give w back.
+	if rw.TryLockTimeout(10 * time.Millisecond) {
+		t.Fatal("TryLockTimeout with an active reader = true")
+	}
+	if !rw.w.TryLock() {
+		t.Fatal("internal mutex still held after TryLockTimeout failed")
+	}
+	rw.w.Unlock()
+
+	// Once the reader leaves, a waiting TryLockTimeout succeeds.
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		rw.RUnlock()
+	}()
+	if !rw.TryLockTimeout(time.Second) {
+		t.Fatal("TryLockTimeout did not pick up the released lock")
+	}
+	rw.Unlock()
+}
@@ -0,0 +1,56 @@
+package sync
+
+import "context"
+
+// A Semaphore is a counting semaphore that limits how many goroutines may
+// hold one of its slots at the same time.
+//
+// Slots are represented by values in a buffered channel, so waiting in
+// Acquire can be abandoned through a context.
+type Semaphore struct {
+	slots chan struct{}
+}
+
+// NewSemaphore returns a Semaphore with n slots. It panics if n < 1.
+func NewSemaphore(n int) *Semaphore {
+	if n < 1 {
+		panic("sync: NewSemaphore with non-positive size")
+	}
+	return &Semaphore{slots: make(chan struct{}, n)}
+}
+
+// Acquire takes a slot, blocking until one is free or ctx is done.
+// On failure it returns ctx.Err() and leaves the semaphore unchanged.
+func (s *Semaphore) Acquire(ctx context.Context) error {
+	// ctx已经结束时不再去抢，否则select可能随机选中有空位的分支
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TryAcquire takes a slot without blocking and reports whether it
+// succeeded.
+func (s *Semaphore) TryAcquire() bool {
+	select {
+	case s.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// Release returns a slot. It panics if called more times than the
+// semaphore has been acquired, which always indicates a bug.
+func (s *Semaphore) Release() {
+	select {
+	case <-s.slots:
+	default:
+		panic("sync: Release of unacquired Semaphore")
+	}
+}
@@ -0,0 +1,63 @@
+package sync
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSemaphore(t *testing.T) {
+	const n = 3
+	s := NewSemaphore(n)
+	ctx := context.Background()
+	for i := 0; i < n; i++ {
+		if err := s.Acquire(ctx); err != nil {
+			t.Fatalf("Acquire #%d = %v", i+1, err)
+		}
+	}
+	if s.TryAcquire() {
+		t.Fatal("TryAcquire on a full semaphore = true")
+	}
+
+	acquired := make(chan error)
+	go func() {
+		acquired <- s.Acquire(ctx)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("Acquire beyond capacity did not block")
+	case <-time.After(10 * time.Millisecond):
+	}
+	s.Release()
+	if err := <-acquired; err != nil {
+		t.Fatalf("Acquire after Release = %v", err)
+	}
+}
+
+func TestSemaphoreAcquireDeadline(t *testing.T) {
+	s := NewSemaphore(1)
+	s.TryAcquire()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Acquire(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Acquire = %v, want context.DeadlineExceeded", err)
+	}
+	// A done context never takes a slot, even a free one.
+	s.Release()
+	if err := s.Acquire(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Acquire with done ctx = %v, want context.DeadlineExceeded", err)
+	}
+	if !s.TryAcquire() {
+		t.Fatal("failed Acquire took a slot")
+	}
+}
+
+func TestSemaphoreReleasePanics(t *testing.T) {
+	s := NewSemaphore(1)
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Release of unacquired Semaphore did not panic")
+		}
+	}()
+	s.Release()
+}
@@ -0,0 +1,69 @@
+package sync
+
+import "time"
+
+// A SlidingWindowCounter counts events in a trailing time window, such as
+// the requests an endpoint received in the last minute.
+//
+// The window is split into a ring of equal buckets; a bucket is reused,
+// and its old count dropped, once time has moved a full window past it.
+// Count therefore has bucket granularity: it covers the current, partly
+// elapsed bucket plus the buckets-1 before it.
+type SlidingWindowCounter struct {
+	mu     RWMutex
+	width  int64   // bucket width in nanoseconds
+	counts []int64 // events per bucket
+	epochs []int64 // which bucket interval (time/width) each slot holds
+	now    func() time.Time
+}
+
+// NewSlidingWindowCounter returns a counter over the given window split
+// into buckets buckets. It panics if window < buckets nanoseconds or
+// buckets < 1.
+func NewSlidingWindowCounter(window time.Duration, buckets int) *SlidingWindowCounter {
+	if buckets < 1 || window < time.Duration(buckets) {
+		panic("sync: NewSlidingWindowCounter with invalid window or buckets")
+	}
+	return &SlidingWindowCounter{
+		width:  int64(window) / int64(buckets),
+		counts: make([]int64, buckets),
+		epochs: make([]int64, buckets),
+		now:    time.Now,
+	}
+}
+
+// epoch returns the index of the bucket interval containing the current
+// time.
+func (c *SlidingWindowCounter) epoch() int64 {
+	return c.now().UnixNano() / c.width
+}
+
+// Incr records one event at the current time.
+func (c *SlidingWindowCounter) Incr() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := c.epoch()
+	i := int(e % int64(len(c.counts)))
+	if c.epochs[i] != e {
+		// 这个槽上次用是一个窗口之前的事了，清零后复用
+		c.epochs[i] = e
+		c.counts[i] = 0
+	}
+	c.counts[i]++
+}
+
+// Count returns the number of events recorded within the trailing window.
+func (c *SlidingWindowCounter) Count() int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e := c.epoch()
+	n := int64(len(c.counts))
+	var total int64
+	for i, count := range c.counts {
+		// 只统计落在最近n个区间里的槽，过期的槽不需要在这里清零
+		if age := e - c.epochs[i]; age >= 0 && age < n {
+			total += count
+		}
+	}
+	return total
+}
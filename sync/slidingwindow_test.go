@@ -0,0 +1,84 @@
+package sync
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSlidingWindowCounter(t *testing.T) {
+	// A 10s window in 2s buckets, driven by a fake clock.
+	c := NewSlidingWindowCounter(10*time.Second, 5)
+	start := time.Unix(1000, 0)
+	now := start
+	c.now = func() time.Time { return now }
+
+	steps := []struct {
+		at    time.Duration // time since start
+		incrs int
+		want  int64
+	}{
+		{at: 0, incrs: 3, want: 3},
+		{at: 3 * time.Second, incrs: 2, want: 5},
+		{at: 9 * time.Second, want: 5},
+		// The bucket holding the first 3 events is now a full window old.
+		{at: 10 * time.Second, want: 2},
+		// Reuses the slot of the first bucket; its old count must not leak.
+		{at: 11 * time.Second, incrs: 1, want: 3},
+		{at: 13 * time.Second, want: 1},
+		// Nothing for longer than the window: everything has expired.
+		{at: time.Minute, want: 0},
+		{at: time.Minute, incrs: 4, want: 4},
+	}
+	for _, s := range steps {
+		now = start.Add(s.at)
+		for i := 0; i < s.incrs; i++ {
+			c.Incr()
+		}
+		if got := c.Count(); got != s.want {
+			t.Fatalf("at %v: Count = %d, want %d", s.at, got, s.want)
+		}
+	}
+}
+
+func TestNewSlidingWindowCounterPanics(t *testing.T) {
+	tests := []struct {
+		window  time.Duration
+		buckets int
+	}{
+		{window: time.Second, buckets: 0},
+		{window: 3, buckets: 5},
+	}
+	for _, tt := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewSlidingWindowCounter(%v, %d) did not panic", tt.window, tt.buckets)
+				}
+			}()
+			NewSlidingWindowCounter(tt.window, tt.buckets)
+		}()
+	}
+}
+
+func TestSlidingWindowCounterConcurrent(t *testing.T) {
+	const (
+		goroutines = 8
+		incrs      = 1000
+	)
+	c := NewSlidingWindowCounter(time.Hour, 60)
+	var wg WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < incrs; j++ {
+				c.Incr()
+				c.Count()
+			}
+		}()
+	}
+	wg.Wait()
+	if got := c.Count(); got != goroutines*incrs {
+		t.Fatalf("Count = %d, want %d", got, goroutines*incrs)
+	}
+}
@@ -0,0 +1,57 @@
+package sync
+
+// A StripedRWMutex is a set of RWMutexes indexed by string keys. Each key
+// is hashed to one of a fixed number of stripes, so goroutines working on
+// different keys usually do not contend, while the same key always maps to
+// the same stripe and is therefore properly excluded.
+//
+// Different keys may share a stripe; callers must not hold the locks of
+// two keys at once unless they take them in a consistent order.
+type StripedRWMutex struct {
+	stripes []RWMutex
+}
+
+// NewStripedRWMutex returns a StripedRWMutex with the given number of
+// stripes. It panics if stripes < 1.
+func NewStripedRWMutex(stripes int) *StripedRWMutex {
+	if stripes < 1 {
+		panic("sync: NewStripedRWMutex with non-positive stripe count")
+	}
+	return &StripedRWMutex{stripes: make([]RWMutex, stripes)}
+}
+
+// stripe returns the RWMutex for key, chosen by the 32-bit FNV-1a hash of
+// the key. The mapping is deterministic for the lifetime of m.
+func (m *StripedRWMutex) stripe(key string) *RWMutex {
+	// FNV-1a，和hash/fnv的New32a结果一致，这里手写一遍避免每次分配
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+	h := uint32(offset32)
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= prime32
+	}
+	return &m.stripes[h%uint32(len(m.stripes))]
+}
+
+// RLock locks the stripe of key for reading.
+func (m *StripedRWMutex) RLock(key string) {
+	m.stripe(key).RLock()
+}
+
+// RUnlock undoes a single RLock call for key.
+func (m *StripedRWMutex) RUnlock(key string) {
+	m.stripe(key).RUnlock()
+}
+
+// Lock locks the stripe of key for writing.
+func (m *StripedRWMutex) Lock(key string) {
+	m.stripe(key).Lock()
+}
+
+// Unlock unlocks the stripe of key for writing.
+func (m *StripedRWMutex) Unlock(key string) {
+	m.stripe(key).Unlock()
+}
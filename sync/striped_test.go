@@ -0,0 +1,114 @@
+package sync
+
+import (
+	"hash/fnv"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStripedRWMutexStripe(t *testing.T) {
+	m := NewStripedRWMutex(16)
+	for i := 0; i < 100; i++ {
+		key := "key" + strconv.Itoa(i)
+		h := fnv.New32a()
+		h.Write([]byte(key))
+		want := &m.stripes[h.Sum32()%16]
+		if got := m.stripe(key); got != want || m.stripe(key) != got {
+			t.Fatalf("stripe(%q) is not the FNV-1a stripe", key)
+		}
+	}
+}
+
+func TestStripedRWMutexExclusion(t *testing.T) {
+	m := NewStripedRWMutex(8)
+	m.Lock("a")
+
+	// The same key is excluded until Unlock.
+	read := make(chan struct{})
+	go func() {
+		m.RLock("a")
+		close(read)
+		m.RUnlock("a")
+	}()
+	select {
+	case <-read:
+		t.Fatal("RLock of a write-locked key succeeded")
+	case <-time.After(10 * time.Millisecond):
+	}
+	m.Unlock("a")
+	select {
+	case <-read:
+	case <-time.After(time.Second):
+		t.Fatal("RLock not released by Unlock of the same key")
+	}
+
+	// Lock and Unlock of the same key pair up under concurrency.
+	const goroutines, iterations = 8, 1000
+	counts := make([]int, 4)
+	var done atomic.Int32
+	finished := make(chan struct{})
+	for g := 0; g < goroutines; g++ {
+		go func() {
+			for i := 0; i < iterations; i++ {
+				k := i % len(counts)
+				key := strconv.Itoa(k)
+				m.Lock(key)
+				counts[k]++
+				m.Unlock(key)
+				m.RLock(key)
+				_ = counts[k]
+				m.RUnlock(key)
+			}
+			if done.Add(1) == goroutines {
+				close(finished)
+			}
+		}()
+	}
+	<-finished
+	for k, n := range counts {
+		if n != goroutines*iterations/len(counts) {
+			t.Fatalf("counts[%d] = %d, want %d", k, n, goroutines*iterations/len(counts))
+		}
+	}
+}
+
+func TestNewStripedRWMutexPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("NewStripedRWMutex(0) did not panic")
+		}
+	}()
+	NewStripedRWMutex(0)
+}
+
+var benchKeys = func() []string {
+	keys := make([]string, 64)
+	for i := range keys {
+		keys[i] = "key" + strconv.Itoa(i)
+	}
+	return keys
+}()
+
+func BenchmarkStripedRWMutexRead(b *testing.B) {
+	m := NewStripedRWMutex(64)
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			key := benchKeys[i%len(benchKeys)]
+			m.RLock(key)
+			m.RUnlock(key)
+		}
+	})
+}
+
+func BenchmarkSingleRWMutexRead(b *testing.B) {
+	var rw RWMutex
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			_ = benchKeys[i%len(benchKeys)]
+			rw.RLock()
+			rw.RUnlock()
+		}
+	})
+}
@@ -0,0 +1,68 @@
+package sync
+
+import "context"
+
+// A TimedWaitGroup is a WaitGroup whose waiters can give up: WaitContext
+// returns when the counter reaches zero or when its context is done.
+//
+// Each time the counter goes from zero to positive a new completion
+// channel is created, and it is closed by the Done that brings the
+// counter back to zero. A waiter observes the channel current at the time
+// it starts waiting, so reusing the group with Add after a Wait has
+// returned is safe.
+//
+// The zero value is ready to use. A TimedWaitGroup must not be copied
+// after first use.
+type TimedWaitGroup struct {
+	mu    Mutex
+	count int
+	// 计数大于0时非nil，计数回到0时关闭并置为nil
+	done chan struct{}
+}
+
+// Add adds delta, which may be negative, to the counter. If the counter
+// becomes zero, all goroutines blocked in Wait or WaitContext are
+// released. If the counter goes negative, Add panics.
+func (wg *TimedWaitGroup) Add(delta int) {
+	wg.mu.Lock()
+	defer wg.mu.Unlock()
+	wg.count += delta
+	switch {
+	case wg.count < 0:
+		panic("sync: negative TimedWaitGroup counter")
+	case wg.count == 0:
+		if wg.done != nil {
+			close(wg.done)
+			wg.done = nil
+		}
+	case wg.done == nil:
+		wg.done = make(chan struct{})
+	}
+}
+
+// Done decrements the counter by one.
+func (wg *TimedWaitGroup) Done() {
+	wg.Add(-1)
+}
+
+// Wait blocks until the counter is zero.
+func (wg *TimedWaitGroup) Wait() {
+	wg.WaitContext(context.Background())
+}
+
+// WaitContext blocks until the counter is zero or ctx is done, in which
+// case it returns ctx.Err().
+func (wg *TimedWaitGroup) WaitContext(ctx context.Context) error {
+	wg.mu.Lock()
+	done := wg.done
+	wg.mu.Unlock()
+	if done == nil {
+		return nil
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
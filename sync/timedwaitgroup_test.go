@@ -0,0 +1,90 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTimedWaitGroupWaitContext(t *testing.T) {
+	const n = 5
+	var wg TimedWaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			time.Sleep(time.Millisecond)
+			wg.Done()
+		}()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := wg.WaitContext(ctx); err != nil {
+		t.Fatalf("WaitContext = %v, want nil", err)
+	}
+	// Waiting on a group at zero returns immediately.
+	if err := wg.WaitContext(ctx); err != nil {
+		t.Fatalf("WaitContext at zero = %v, want nil", err)
+	}
+}
+
+func TestTimedWaitGroupDeadline(t *testing.T) {
+	var wg TimedWaitGroup
+	wg.Add(1) // never Done
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := wg.WaitContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("WaitContext = %v, want DeadlineExceeded", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("WaitContext returned after %v, want about 10ms", d)
+	}
+}
+
+func TestTimedWaitGroupReuse(t *testing.T) {
+	var wg TimedWaitGroup
+	wg.Add(1)
+	wg.Done()
+	wg.Wait()
+
+	// Add after a Wait has returned starts a new round; a waiter must not
+	// be released by the previous round's completion.
+	wg.Add(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := wg.WaitContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("WaitContext in second round = %v, want DeadlineExceeded", err)
+	}
+
+	waited := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(waited)
+	}()
+	// Add while a waiter is blocked keeps it blocked until the counter
+	// reaches zero again.
+	wg.Add(1)
+	wg.Done()
+	select {
+	case <-waited:
+		t.Fatal("Wait returned while the counter was still positive")
+	case <-time.After(10 * time.Millisecond):
+	}
+	wg.Done()
+	select {
+	case <-waited:
+	case <-time.After(time.Second):
+		t.Fatal("Wait not released when the counter reached zero")
+	}
+}
+
+func TestTimedWaitGroupNegative(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Done on a zero counter did not panic")
+		}
+	}()
+	var wg TimedWaitGroup
+	wg.Done()
+}
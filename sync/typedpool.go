@@ -0,0 +1,61 @@
+package sync
+
+// A TypedPool is a set of reusable objects of type T, like sync.Pool but
+// typed and with a Reset hook so objects never come back dirty.
+//
+// Unlike sync.Pool, idle objects are kept in a plain free list and are not
+// dropped by the garbage collector; set MaxIdle to bound how many are
+// retained.
+//
+// A TypedPool must not be copied after first use.
+type TypedPool[T any] struct {
+	// New creates an object when the pool is empty. If nil, Get returns a
+	// pointer to the zero T.
+	New func() T
+	// Reset, if non-nil, is called by Put before the object is stored, to
+	// clear any state left by the previous user.
+	Reset func(*T)
+	// MaxIdle limits how many idle objects are kept; 0 means no limit.
+	// Objects Put beyond the limit are dropped.
+	MaxIdle int
+
+	mu   Mutex
+	free []*T
+}
+
+// Get takes an object from the pool, creating one with New if the pool is
+// empty. Objects that went through Put have already been reset.
+func (p *TypedPool[T]) Get() *T {
+	p.mu.Lock()
+	if n := len(p.free); n > 0 {
+		x := p.free[n-1]
+		p.free[n-1] = nil
+		p.free = p.free[:n-1]
+		p.mu.Unlock()
+		return x
+	}
+	p.mu.Unlock()
+
+	x := new(T)
+	if p.New != nil {
+		*x = p.New()
+	}
+	return x
+}
+
+// Put resets x and returns it to the pool. Put of a nil pointer is a no-op.
+func (p *TypedPool[T]) Put(x *T) {
+	if x == nil {
+		return
+	}
+	// 在锁外Reset，Reset可能比较慢（比如清空大buffer）
+	if p.Reset != nil {
+		p.Reset(x)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.MaxIdle > 0 && len(p.free) >= p.MaxIdle {
+		return
+	}
+	p.free = append(p.free, x)
+}
@@ -0,0 +1,100 @@
+package sync
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTypedPoolReset(t *testing.T) {
+	var resets int
+	p := &TypedPool[bytes.Buffer]{
+		Reset: func(b *bytes.Buffer) {
+			resets++
+			b.Reset()
+		},
+	}
+	b := p.Get()
+	b.WriteString("secret")
+	p.Put(b)
+	if resets != 1 {
+		t.Fatalf("Reset called %d times on Put, want 1", resets)
+	}
+
+	got := p.Get()
+	if got != b {
+		t.Fatal("Get did not reuse the object returned by Put")
+	}
+	if got.Len() != 0 {
+		t.Fatalf("Get returned a dirty buffer: %q", got.String())
+	}
+}
+
+func TestTypedPoolNew(t *testing.T) {
+	var created int
+	p := &TypedPool[[]byte]{
+		New: func() []byte {
+			created++
+			return make([]byte, 0, 64)
+		},
+		Reset: func(b *[]byte) { *b = (*b)[:0] },
+	}
+	a, b := p.Get(), p.Get()
+	if created != 2 || a == b {
+		t.Fatalf("created %d objects for 2 Gets on an empty pool, want 2 distinct", created)
+	}
+	if cap(*a) != 64 {
+		t.Fatalf("cap = %d, want 64 from New", cap(*a))
+	}
+
+	// Objects come back by pointer identity; New is not called again.
+	p.Put(a)
+	p.Put(b)
+	seen := map[*[]byte]bool{p.Get(): true, p.Get(): true}
+	if !seen[a] || !seen[b] {
+		t.Fatal("pool did not reuse the objects it was given")
+	}
+	if created != 2 {
+		t.Fatalf("New called %d times, want 2", created)
+	}
+
+	// Without New, Get returns a pointer to the zero value.
+	var zp TypedPool[int]
+	if x := zp.Get(); x == nil || *x != 0 {
+		t.Fatalf("Get without New = %v, want pointer to 0", x)
+	}
+}
+
+func TestTypedPoolMaxIdle(t *testing.T) {
+	p := &TypedPool[int]{MaxIdle: 1}
+	a, b := p.Get(), p.Get()
+	p.Put(a)
+	p.Put(b) // dropped: the pool already holds MaxIdle objects
+	p.Put(nil)
+	if got := p.Get(); got != a {
+		t.Fatal("Get did not return the retained object")
+	}
+	if got := p.Get(); got == b {
+		t.Fatal("object Put beyond MaxIdle was retained")
+	}
+}
+
+func TestTypedPoolConcurrent(t *testing.T) {
+	p := &TypedPool[bytes.Buffer]{Reset: (*bytes.Buffer).Reset}
+	var wg WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				b := p.Get()
+				if b.Len() != 0 {
+					t.Error("Get returned a dirty buffer")
+					return
+				}
+				b.WriteString("data")
+				p.Put(b)
+			}
+		}()
+	}
+	wg.Wait()
+}
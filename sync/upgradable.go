@@ -0,0 +1,105 @@
+package sync
+
+import "sync/atomic"
+
+// States of the upgradable slot of an UpgradableRWMutex.
+const (
+	upgradableFree     int32 = iota // nobody holds the slot
+	upgradableReading               // the slot holder has a read lock
+	upgradableUpgraded              // the slot holder has upgraded to a write lock
+)
+
+// An UpgradableRWMutex is a reader/writer lock with a third, upgradable
+// read mode. A goroutine holding the upgradable read lock shares the lock
+// with ordinary readers, and can later Upgrade it to a write lock without
+// letting any other writer in between, and Downgrade it again.
+//
+// Only one goroutine may hold the upgradable slot at a time: two readers
+// that both wanted to upgrade would wait for each other forever. Writers
+// take the same slot before locking, which is what keeps them from
+// slipping in while an upgrade is in progress.
+//
+// Only a read lock taken with UpgradableRLock can be upgraded. A read
+// lock taken with RLock is an ordinary read lock; calling Upgrade while
+// holding only that is a fatal error, because the caller does not hold the
+// slot and nothing would stop a second reader from upgrading at the same
+// time.
+//
+// The zero value for an UpgradableRWMutex is an unlocked mutex.
+// An UpgradableRWMutex must not be copied after first use.
+type UpgradableRWMutex struct {
+	rw RWMutex
+	// 可升级的名额，持有可升级读锁的goroutine和写者都要先拿到它
+	slot Mutex
+	// 名额当前的状态，用来检查Upgrade/Downgrade的调用顺序
+	state atomic.Int32
+}
+
+// RLock locks m for ordinary (non-upgradable) reading. A read lock taken
+// with RLock cannot be upgraded; use UpgradableRLock for that.
+func (m *UpgradableRWMutex) RLock() {
+	m.rw.RLock()
+}
+
+// RUnlock undoes a single RLock call.
+func (m *UpgradableRWMutex) RUnlock() {
+	m.rw.RUnlock()
+}
+
+// UpgradableRLock locks m for reading and takes the upgradable slot. It
+// blocks while another goroutine holds the slot or a write lock.
+func (m *UpgradableRWMutex) UpgradableRLock() {
+	m.slot.Lock()
+	m.rw.RLock()
+	m.state.Store(upgradableReading)
+}
+
+// UpgradableRUnlock releases the upgradable read lock and the slot.
+func (m *UpgradableRWMutex) UpgradableRUnlock() {
+	if !m.state.CompareAndSwap(upgradableReading, upgradableFree) {
+		fatal("sync: UpgradableRUnlock without UpgradableRLock")
+	}
+	m.rw.RUnlock()
+	m.slot.Unlock()
+}
+
+// Upgrade converts the upgradable read lock held by the caller into a
+// write lock, blocking until all ordinary readers have left. It is a fatal
+// error if the caller does not hold the upgradable read lock, including when
+// it holds only an ordinary read lock from RLock.
+//
+// Because every writer has to take the slot first, no other writer can
+// acquire m while the upgrade is waiting. Ordinary readers may still come
+// and go, but they cannot modify the protected state.
+func (m *UpgradableRWMutex) Upgrade() {
+	if !m.state.CompareAndSwap(upgradableReading, upgradableUpgraded) {
+		fatal("sync: Upgrade without UpgradableRLock")
+	}
+	// 先放掉自己的读锁再去拿写锁，中间的空档只有普通读者能进来
+	m.rw.RUnlock()
+	m.rw.Lock()
+}
+
+// Downgrade converts the write lock obtained by Upgrade back into an
+// upgradable read lock, without releasing the slot.
+func (m *UpgradableRWMutex) Downgrade() {
+	if !m.state.CompareAndSwap(upgradableUpgraded, upgradableReading) {
+		fatal("sync: Downgrade without Upgrade")
+	}
+	m.rw.DowngradeToRead()
+}
+
+// Lock locks m for writing. It waits for the upgradable slot as well as
+// for all readers.
+func (m *UpgradableRWMutex) Lock() {
+	m.slot.Lock()
+	m.rw.Lock()
+}
+
+// Unlock releases a write lock obtained by Lock or by Upgrade. After an
+// Upgrade it also gives up the upgradable slot.
+func (m *UpgradableRWMutex) Unlock() {
+	m.state.CompareAndSwap(upgradableUpgraded, upgradableFree)
+	m.rw.Unlock()
+	m.slot.Unlock()
+}
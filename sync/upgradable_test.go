@@ -0,0 +1,82 @@
+package sync
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUpgradableRWMutexUpgrade(t *testing.T) {
+	var m UpgradableRWMutex
+	value := 1
+
+	m.UpgradableRLock()
+	// Ordinary readers share the lock with the upgradable reader.
+	if !m.rw.TryRLock() {
+		t.Fatal("RLock blocked by UpgradableRLock")
+	}
+	m.RUnlock()
+	read := value
+
+	// A writer that arrives now has to wait for the whole read-upgrade-write
+	// sequence, so it must observe the value written after the Upgrade.
+	seen := make(chan int)
+	go func() {
+		m.Lock()
+		seen <- value
+		m.Unlock()
+	}()
+	select {
+	case <-seen:
+		t.Fatal("Lock acquired while the upgradable read lock was held")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	m.Upgrade()
+	select {
+	case <-seen:
+		t.Fatal("Lock acquired during Upgrade")
+	case <-time.After(10 * time.Millisecond):
+	}
+	value = read + 1
+	m.Unlock()
+
+	select {
+	case v := <-seen:
+		if v != 2 {
+			t.Fatalf("writer saw %d, want 2", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Lock not acquired after Unlock of the upgraded lock")
+	}
+}
+
+func TestUpgradableRWMutexDowngrade(t *testing.T) {
+	var m UpgradableRWMutex
+	m.UpgradableRLock()
+	m.Upgrade()
+
+	// A reader blocked by the upgraded lock is let in by Downgrade.
+	read := make(chan struct{})
+	go func() {
+		m.RLock()
+		close(read)
+	}()
+	select {
+	case <-read:
+		t.Fatal("RLock acquired while the lock was upgraded")
+	case <-time.After(10 * time.Millisecond):
+	}
+	m.Downgrade()
+	select {
+	case <-read:
+	case <-time.After(time.Second):
+		t.Fatal("blocked reader not released by Downgrade")
+	}
+	m.RUnlock()
+
+	// After Downgrade the caller still holds the slot and may upgrade again.
+	m.Upgrade()
+	m.Unlock()
+	m.Lock()
+	m.Unlock()
+}
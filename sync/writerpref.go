@@ -0,0 +1,61 @@
+package sync
+
+// A WriterPreferringRWMutex is a reader/writer lock with an optional,
+// stricter preference for writers.
+//
+// With Strict unset it behaves exactly like RWMutex: a writer that has
+// started waiting for active readers blocks new readers, but when a
+// writer unlocks, the readers it blocked are let in before the next
+// writer, so under mixed load the lock leans towards readers.
+//
+// With Strict set, a writer blocks new readers from the moment it calls
+// Lock, including while it is still queued behind other writers, and
+// keeps them out until it has acquired the lock. Queued writers are then
+// served back to back ahead of any reader that arrived after them.
+//
+// The tradeoff is fairness: in strict mode a steady stream of writers can
+// starve readers indefinitely, whereas the default mode can delay writers
+// under heavy read load. Strict mode also costs every RLock an extra
+// uncontended Mutex round trip.
+//
+// Strict must be set before first use and not changed afterwards.
+// The zero value is an unlocked mutex in the default mode.
+type WriterPreferringRWMutex struct {
+	// Strict selects the stricter writer preference described above.
+	Strict bool
+
+	rw RWMutex
+	// 严格模式下写者从开始等待到拿到锁一直持有gate，新来的读者必须先过gate
+	gate Mutex
+}
+
+// RLock locks m for reading.
+func (m *WriterPreferringRWMutex) RLock() {
+	if m.Strict {
+		// 有写者在排队时会卡在这里，直到写者拿到锁
+		m.gate.Lock()
+		m.gate.Unlock()
+	}
+	m.rw.RLock()
+}
+
+// RUnlock undoes a single RLock call.
+func (m *WriterPreferringRWMutex) RUnlock() {
+	m.rw.RUnlock()
+}
+
+// Lock locks m for writing.
+func (m *WriterPreferringRWMutex) Lock() {
+	if m.Strict {
+		m.gate.Lock()
+		m.rw.Lock()
+		m.gate.Unlock()
+		return
+	}
+	m.rw.Lock()
+}
+
+// Unlock unlocks m for writing.
+func (m *WriterPreferringRWMutex) Unlock() {
+	m.rw.Unlock()
+}
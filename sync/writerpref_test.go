@@ -0,0 +1,79 @@
+package sync
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// acquireOrder holds m for writing, queues a second writer and then a
+// reader behind it, and reports the order in which the two got the lock
+// once the first writer unlocks.
+func acquireOrder(t *testing.T, m *WriterPreferringRWMutex) []string {
+	t.Helper()
+	order := make(chan string, 2)
+	m.Lock()
+
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		m.Lock()
+		order <- "writer"
+		m.Unlock()
+	}()
+	// There is no way to observe a goroutine queued on the internal
+	// Mutexes, so give each one time to block before the next arrives.
+	time.Sleep(20 * time.Millisecond)
+
+	readerDone := make(chan struct{})
+	go func() {
+		defer close(readerDone)
+		m.RLock()
+		order <- "reader"
+		m.RUnlock()
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	m.Unlock()
+	for _, done := range []chan struct{}{writerDone, readerDone} {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("queued goroutines did not get the lock")
+		}
+	}
+	return []string{<-order, <-order}
+}
+
+func TestWriterPreferringRWMutexStrict(t *testing.T) {
+	m := &WriterPreferringRWMutex{Strict: true}
+	// The queued writer is served before the reader that arrived after it.
+	if got, want := acquireOrder(t, m), []string{"writer", "reader"}; !slices.Equal(got, want) {
+		t.Fatalf("strict order = %v, want %v", got, want)
+	}
+}
+
+func TestWriterPreferringRWMutexDefault(t *testing.T) {
+	var m WriterPreferringRWMutex
+	// Like RWMutex, the readers blocked by a writer go before the next writer.
+	if got, want := acquireOrder(t, &m), []string{"reader", "writer"}; !slices.Equal(got, want) {
+		t.Fatalf("default order = %v, want %v", got, want)
+	}
+}
+
+func TestWriterPreferringRWMutexReadersShare(t *testing.T) {
+	m := &WriterPreferringRWMutex{Strict: true}
+	m.RLock()
+	done := make(chan struct{})
+	go func() {
+		m.RLock()
+		m.RUnlock()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("second reader blocked without a writer")
+	}
+	m.RUnlock()
+}